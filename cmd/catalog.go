@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/ddazal/marcopolo-go/internal/config"
+	"github.com/ddazal/marcopolo-go/internal/mcp"
+	"github.com/ddazal/marcopolo-go/internal/tools"
+	"github.com/ddazal/marcopolo-go/internal/upstream"
+)
+
+// toolCatalog holds the tools of one namespace: the Go tools, which every
+// namespace shares, the namespace's definition files and its upstream servers.
+// Both index and serve build catalogs, so they see the same tools.
+type toolCatalog struct {
+	namespace string
+	files     []tools.FileTool
+	handlers  map[string]mcp.ToolHandler
+	pool      *upstream.Pool
+}
+
+// namespaceSources returns the tools_dir and upstreams of a namespace. The default
+// namespace uses the top-level settings; the others must be configured under namespaces.
+func namespaceSources(namespace string) (string, []config.UpstreamConfig, error) {
+	if namespace == "" {
+		return appConfig.ToolsDir, appConfig.Upstreams, nil
+	}
+	if !mcp.ValidNamespace(namespace) {
+		return "", nil, fmt.Errorf("invalid namespace %q: use lowercase letters, digits, _ and -", namespace)
+	}
+	ns, ok := appConfig.Namespaces[namespace]
+	if !ok {
+		return "", nil, fmt.Errorf("namespace %q is not configured under namespaces in config.yaml", namespace)
+	}
+	return ns.ToolsDir, ns.Upstreams, nil
+}
+
+// loadCatalog loads the tool files of a namespace and prepares its upstream pool.
+// Upstream sessions are opened on first use. File tools may not reuse the name
+// of a Go tool.
+func loadCatalog(namespace string) (*toolCatalog, error) {
+	toolsDir, upstreams, err := namespaceSources(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &toolCatalog{namespace: namespace, handlers: make(map[string]mcp.ToolHandler)}
+	if toolsDir != "" {
+		catalog.files, err = tools.LoadDir(toolsDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tools from %s: %w", toolsDir, err)
+		}
+	}
+	for _, tool := range catalog.files {
+		if _, exists := tools.GetTool(tool.Definition.Name); exists {
+			return nil, fmt.Errorf("%s: tool %q is already registered", tool.Path, tool.Definition.Name)
+		}
+		catalog.handlers[tool.Definition.Name] = tool.Handler
+	}
+
+	catalog.pool, err = upstream.NewPool(upstreams)
+	if err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// loadCatalogs loads the catalogs of the default namespace and every configured one.
+func loadCatalogs() (map[string]*toolCatalog, error) {
+	namespaces := []string{""}
+	for name := range appConfig.Namespaces {
+		namespaces = append(namespaces, name)
+	}
+
+	catalogs := make(map[string]*toolCatalog, len(namespaces))
+	for _, name := range namespaces {
+		catalog, err := loadCatalog(name)
+		if err != nil {
+			closeCatalogs(catalogs)
+			return nil, err
+		}
+		catalogs[name] = catalog
+	}
+	return catalogs, nil
+}
+
+func closeCatalogs(catalogs map[string]*toolCatalog) {
+	for _, catalog := range catalogs {
+		catalog.Close()
+	}
+}
+
+// definitions returns the Go and file tools followed by the tools of every
+// reachable upstream. Unreachable upstreams are reported on warn and returned
+// in unavailable, keyed by name.
+func (c *toolCatalog) definitions(ctx context.Context, warn io.Writer) ([]tools.ToolDefinition, map[string]bool, error) {
+	defs := slices.Clone(tools.GetAllTools())
+	for _, tool := range c.files {
+		defs = append(defs, tool.Definition)
+	}
+
+	upstreamTools, unavailable := listUpstreamTools(ctx, warn, c.pool)
+	defs, err := mergeUpstreamTools(defs, upstreamTools)
+	if err != nil {
+		return nil, nil, err
+	}
+	return defs, unavailable, nil
+}
+
+// CallTool executes a file or upstream tool of the namespace.
+func (c *toolCatalog) CallTool(ctx context.Context, name string, arguments json.RawMessage) (interface{}, bool, error) {
+	if handler, ok := c.handlers[name]; ok {
+		result, err := handler(ctx, arguments)
+		return result, true, err
+	}
+	return c.pool.CallTool(ctx, name, arguments)
+}
+
+// Close closes the sessions of the namespace's upstream servers.
+func (c *toolCatalog) Close() error {
+	return c.pool.Close()
+}
+
+// namespaceProxy implements mcp.ToolProxy by calling the catalog of the session's namespace
+type namespaceProxy map[string]*toolCatalog
+
+func (p namespaceProxy) CallTool(ctx context.Context, name string, arguments json.RawMessage) (interface{}, bool, error) {
+	catalog, ok := p[mcp.NamespaceFromContext(ctx)]
+	if !ok {
+		return nil, false, nil
+	}
+	return catalog.CallTool(ctx, name, arguments)
+}
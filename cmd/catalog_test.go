@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ddazal/marcopolo-go/internal/config"
+	"github.com/ddazal/marcopolo-go/internal/tools"
+	"github.com/ddazal/marcopolo-go/internal/upstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useConfig replaces appConfig for the duration of a test
+func useConfig(t *testing.T, cfg *config.Config) {
+	t.Helper()
+	previous := appConfig
+	appConfig = cfg
+	t.Cleanup(func() { appConfig = previous })
+}
+
+func TestNamespaceSources(t *testing.T) {
+	useConfig(t, &config.Config{
+		ToolsDir:   "tools",
+		Namespaces: map[string]config.NamespaceConfig{"acme": {ToolsDir: "acme-tools"}},
+	})
+
+	dir, _, err := namespaceSources("")
+	require.NoError(t, err)
+	assert.Equal(t, "tools", dir)
+
+	dir, _, err = namespaceSources("acme")
+	require.NoError(t, err)
+	assert.Equal(t, "acme-tools", dir)
+
+	_, _, err = namespaceSources("globex")
+	assert.ErrorContains(t, err, "is not configured")
+
+	_, _, err = namespaceSources("Acme Corp")
+	assert.ErrorContains(t, err, "invalid namespace")
+}
+
+func TestLoadCatalog_RejectsRegisteredName(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "holidays.yaml"),
+		[]byte("name: get_holidays\ndescription: Holidays\nexecutor: {type: go, handler: get_holidays}"), 0o644))
+	useConfig(t, &config.Config{ToolsDir: dir})
+
+	_, err := loadCatalog("")
+	assert.ErrorContains(t, err, `tool "get_holidays" is already registered`)
+}
+
+func TestToolCatalog_DefinitionsDoNotShareRegistry(t *testing.T) {
+	registered := tools.GetAllTools()
+	require.NotEmpty(t, registered)
+	name := registered[0].Name
+
+	catalog := &toolCatalog{pool: &upstream.Pool{}}
+	defs, _, err := catalog.definitions(context.Background(), io.Discard)
+	require.NoError(t, err)
+
+	defs[0].Name = "renamed"
+	assert.Equal(t, name, tools.GetAllTools()[0].Name)
+}
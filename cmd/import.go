@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// importCmd is a command group for generating tools from other descriptions.
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Generate tool definitions from API descriptions",
+	Long: `Generate tool definition files from API descriptions.
+
+Generated files are written to tools_dir, where index and serve load them like
+hand-written definitions.`,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.AddCommand(importOpenAPICmd)
+}
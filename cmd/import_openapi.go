@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ddazal/marcopolo-go/internal/openapi"
+	"github.com/ddazal/marcopolo-go/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importBaseURL  string
+	importInclude  []string
+	importExclude  []string
+	importCategory string
+	importOwner    string
+	importOutput   string
+)
+
+var importOpenAPICmd = &cobra.Command{
+	Use:   "openapi <spec.yaml>",
+	Short: "Generate tools from an OpenAPI 3 spec",
+	Long: `Generate one tool per operation of an OpenAPI 3 spec (YAML or JSON).
+
+Each tool is named after the operation's operationId and described by its
+summary and description. Path, query and header parameters and the properties
+of a JSON request body become the tool's parameters, and the tool runs with an
+http executor against the base URL.
+
+The tools are written to <tools_dir>/<spec name>.yaml, replacing the file of a
+previous import. Run index afterwards to embed them.
+
+Filters select operations by tag, or by path when they start with /, where *
+matches any characters:
+  marcopolo-go import openapi petstore.yaml --include pets --exclude '/admin/*'`,
+	Args: cobra.ExactArgs(1),
+	RunE: importOpenAPI,
+}
+
+func init() {
+	importOpenAPICmd.Flags().StringVar(
+		&importBaseURL,
+		"base-url",
+		"",
+		"Base URL the tools call (default: the spec's first server URL)",
+	)
+	importOpenAPICmd.Flags().StringArrayVar(
+		&importInclude,
+		"include",
+		nil,
+		"Only import operations with this tag or path; repeatable",
+	)
+	importOpenAPICmd.Flags().StringArrayVar(
+		&importExclude,
+		"exclude",
+		nil,
+		"Skip operations with this tag or path; repeatable",
+	)
+	importOpenAPICmd.Flags().StringVar(
+		&importCategory,
+		"category",
+		"",
+		"Category set on every generated tool",
+	)
+	importOpenAPICmd.Flags().StringVar(
+		&importOwner,
+		"owner",
+		"",
+		"Owner set on every generated tool",
+	)
+	importOpenAPICmd.Flags().StringVarP(
+		&importOutput,
+		"output",
+		"o",
+		"",
+		"File to write, or - for stdout (default: <tools_dir>/<spec name>.yaml)",
+	)
+}
+
+func importOpenAPI(cmd *cobra.Command, args []string) error {
+	specPath := args[0]
+
+	output := importOutput
+	if output == "" {
+		if appConfig.ToolsDir == "" {
+			return fmt.Errorf("tools_dir is not configured; set it or pass --output")
+		}
+		name := strings.TrimSuffix(filepath.Base(specPath), filepath.Ext(specPath))
+		output = filepath.Join(appConfig.ToolsDir, name+".yaml")
+	}
+
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		return fmt.Errorf("failed to read spec: %w", err)
+	}
+
+	result, err := openapi.Import(data, openapi.Options{
+		BaseURL:  importBaseURL,
+		Include:  importInclude,
+		Exclude:  importExclude,
+		Category: importCategory,
+		Owner:    importOwner,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", specPath, err)
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s\n", warning)
+	}
+	if len(result.Tools) == 0 {
+		return fmt.Errorf("no operations in %s matched", specPath)
+	}
+
+	encoded, err := tools.EncodeFile(result.Tools)
+	if err != nil {
+		return fmt.Errorf("failed to encode tools: %w", err)
+	}
+	header := fmt.Sprintf("# Generated by marcopolo-go import openapi from %s.\n# Re-run the import instead of editing this file.\n", filepath.Base(specPath))
+	content := append([]byte(header), encoded...)
+
+	if output == "-" {
+		_, err := cmd.OutOrStdout().Write(content)
+		return err
+	}
+	if err := os.WriteFile(output, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write tools: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Imported %d operations to %s; run index to embed them\n", len(result.Tools), output)
+	return nil
+}
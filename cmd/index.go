@@ -2,12 +2,19 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/ddazal/marcopolo-go/internal/db"
 	"github.com/ddazal/marcopolo-go/internal/embeddings"
 	"github.com/ddazal/marcopolo-go/internal/models"
+	"github.com/ddazal/marcopolo-go/internal/textdiff"
 	"github.com/ddazal/marcopolo-go/internal/tools"
+	"github.com/ddazal/marcopolo-go/internal/upstream"
 	"github.com/pgvector/pgvector-go"
 	"github.com/spf13/cobra"
 )
@@ -19,8 +26,10 @@ var indexCmd = &cobra.Command{
 	Long: `Generate embeddings for all registered tools and store them in the database.
 
 This command:
-- Retrieves all registered tools from the tool registry
-- Generates embeddings using the configured provider
+- Retrieves all registered tools from the tool registry, the tool definition
+  files in tools_dir and the configured upstream MCP servers
+- Generates embeddings in batches using the configured provider, one for each
+  tool's description and one for each of its example queries
 - Stores tool metadata and embeddings in the database for similarity search
 
 The embedding provider and model can be configured in config.yaml:
@@ -28,17 +37,265 @@ The embedding provider and model can be configured in config.yaml:
     provider: "openai"
     model: "text-embedding-3-small"
 
-Re-running this command is safe - it uses upsert to update existing tools.`,
+The embedded text is rendered by a Go text/template, which by default includes
+the tool's name, description and parameter names, types, descriptions and
+allowed values. Override it with embedding.template:
+  embedding:
+    template: |
+      {{.Name}}: {{.Description}}
+      {{- range .Parameters}}
+      {{.Name}}: {{.Description}}
+      {{- end}}
+
+Re-running this command is safe and incremental: each row stores a hash of the
+tool's description, the embedding provider/model and the template version, and
+tools whose hash, model and template are unchanged are skipped. A tool whose
+tags, category, owner or source changed, but nothing that is embedded, keeps its
+embeddings and only has those columns updated. Use --force to re-embed every tool.
+
+Tools that are indexed but no longer registered stay searchable until the
+command runs with --prune, which soft-deletes them.
+
+Upstream tools are stored as <upstream>.<tool>. An upstream that cannot be
+reached is reported and its indexed tools are left as they are, neither updated
+nor pruned; the command still indexes everything else and then exits non-zero.
+
+With --dry-run, index prints what it would do without writing to the database:
+a table of new, changed, unchanged and orphaned tools, followed by a unified
+diff of every changed description, input schema and example list. Nothing is embedded unless
+--embed is also given, which embeds only new and changed tools to check that the
+provider works. The command exits non-zero when changes are pending, so it can
+be used as a deploy gate.
+
+Each run indexes one namespace. Without --namespace it indexes the default
+namespace from tools_dir and upstreams; --namespace=<name> indexes the
+tools_dir and upstreams configured under namespaces.<name>. Go tools are
+indexed in every namespace, and pruning only touches the selected namespace.`,
 	RunE: indexTools,
 }
 
+var (
+	indexForce     bool
+	indexPrune     bool
+	indexDryRun    bool
+	indexEmbed     bool
+	indexNamespace string
+)
+
 func init() {
 	rootCmd.AddCommand(indexCmd)
+
+	indexCmd.Flags().BoolVar(
+		&indexForce,
+		"force",
+		false,
+		"Re-embed every tool, even if its description has not changed",
+	)
+	indexCmd.Flags().BoolVar(
+		&indexPrune,
+		"prune",
+		false,
+		"Soft-delete indexed tools that are no longer registered",
+	)
+	indexCmd.Flags().BoolVar(
+		&indexDryRun,
+		"dry-run",
+		false,
+		"Show pending changes without writing to the database; exits non-zero if there are any",
+	)
+	indexCmd.Flags().BoolVar(
+		&indexEmbed,
+		"embed",
+		false,
+		"With --dry-run, embed new and changed tools to verify the embedding provider",
+	)
+	indexCmd.Flags().StringVar(
+		&indexNamespace,
+		"namespace",
+		"",
+		"Namespace to index, as configured under namespaces (default: the default namespace)",
+	)
+}
+
+// indexAction describes what index does with a registered tool.
+type indexAction string
+
+const (
+	indexAdd       indexAction = "added"
+	indexUpdate    indexAction = "updated"
+	indexUnchanged indexAction = "unchanged"
+)
+
+// indexEntry pairs a registered tool with its stored row and the planned action.
+type indexEntry struct {
+	def         tools.ToolDefinition
+	description tools.ToolDescription
+	existing    *models.Tool // nil if the tool is not in the database yet
+	action      indexAction
+	reasons     []string // why an existing tool is updated
+	reembed     bool     // whether the tool is embedded; updates of metadata alone keep the stored embeddings
+}
+
+// metadataReason is the change reason of fields that are stored but not embedded
+const metadataReason = "metadata"
+
+// orphanedTools returns stored tools whose names are not among the registered definitions.
+// Tools from the unavailable sources are not orphaned, since their upstream could
+// not say whether they still exist.
+func orphanedTools(defs []tools.ToolDefinition, stored []*models.Tool, unavailable map[string]bool) []*models.Tool {
+	registered := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		registered[def.Name] = true
+	}
+
+	var orphaned []*models.Tool
+	for _, tool := range stored {
+		if !registered[tool.Name] && !unavailable[tool.Source] {
+			orphaned = append(orphaned, tool)
+		}
+	}
+	return orphaned
+}
+
+// planIndex compares registered tools against stored rows. A tool is unchanged
+// when its content hash, embedding provider/model and template version all match
+// the stored row. With force, every stored tool is updated.
+func planIndex(defs []tools.ToolDefinition, stored []*models.Tool, describer *tools.Describer, provider, model string, force bool) ([]indexEntry, error) {
+	byName := make(map[string]*models.Tool, len(stored))
+	for _, tool := range stored {
+		byName[tool.Name] = tool
+	}
+
+	entries := make([]indexEntry, len(defs))
+	for i, def := range defs {
+		description, err := describer.Describe(def)
+		if err != nil {
+			return nil, fmt.Errorf("could not describe tool %q: %w", def.Name, err)
+		}
+
+		entry := indexEntry{
+			def:         def,
+			description: description,
+			existing:    byName[def.Name],
+		}
+
+		if entry.existing == nil {
+			entry.action = indexAdd
+			entry.reembed = true
+			entries[i] = entry
+			continue
+		}
+
+		entry.reasons = changeReasons(entry.existing, def, description, provider, model)
+		if force && len(entry.reasons) == 0 {
+			entry.reasons = []string{"forced"}
+		}
+
+		entry.action = indexUnchanged
+		if len(entry.reasons) > 0 {
+			entry.action = indexUpdate
+		}
+		entry.reembed = force || slices.ContainsFunc(entry.reasons, func(reason string) bool {
+			return reason != metadataReason
+		})
+
+		entries[i] = entry
+	}
+
+	return entries, nil
 }
 
-func indexTools(_ *cobra.Command, _ []string) error {
+// changeReasons lists what differs between a stored tool and its current definition.
+// A hash mismatch that shows no visible difference (e.g. rows indexed before hashes
+// were stored) is reported as a content hash change. Metadata is not embedded, so
+// a change reported only as metadataReason does not need new embeddings.
+func changeReasons(stored *models.Tool, def tools.ToolDefinition, description tools.ToolDescription, provider, model string) []string {
+	var reasons []string
+
+	if stored.ContentHash != description.ContentHash() {
+		if stored.Description != description.Text {
+			reasons = append(reasons, "description")
+		}
+		if normalizeSchema(stored.InputSchema) != normalizeSchema(description.InputSchema) {
+			reasons = append(reasons, "input schema")
+		}
+		if !slices.Equal([]string(stored.Examples), description.Examples) {
+			reasons = append(reasons, "examples")
+		}
+		if len(reasons) == 0 {
+			reasons = append(reasons, "content hash")
+		}
+	}
+
+	if !slices.Equal([]string(stored.Tags), def.Tags) || stored.Category != def.Category ||
+		stored.Owner != def.Owner || stored.Source != def.Source {
+		reasons = append(reasons, metadataReason)
+	}
+
+	if stored.EmbeddingTemplate != description.TemplateVersion {
+		reasons = append(reasons, fmt.Sprintf("template %s -> %s",
+			templateLabel(stored.EmbeddingTemplate), templateLabel(description.TemplateVersion)))
+	}
+
+	if stored.EmbeddingProvider != provider || stored.EmbeddingModel != model {
+		reasons = append(reasons, fmt.Sprintf("embedding model %s -> %s",
+			modelLabel(stored.EmbeddingProvider, stored.EmbeddingModel), modelLabel(provider, model)))
+	}
+
+	return reasons
+}
+
+func modelLabel(provider, model string) string {
+	if provider == "" && model == "" {
+		return "(none)"
+	}
+	return provider + "/" + model
+}
+
+func templateLabel(version string) string {
+	if version == "" {
+		return "(none)"
+	}
+	return version
+}
+
+// normalizeSchema pretty-prints a JSON schema with sorted keys, so schemas read back
+// from jsonb compare and diff cleanly against freshly marshalled ones.
+// Invalid JSON is returned unchanged.
+func normalizeSchema(schema *string) string {
+	if schema == nil {
+		return ""
+	}
+
+	var v any
+	if err := json.Unmarshal([]byte(*schema), &v); err != nil {
+		return *schema
+	}
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return *schema
+	}
+	return string(out) + "\n"
+}
+
+func indexTools(cmd *cobra.Command, _ []string) error {
 	ctx := context.Background()
 
+	if indexEmbed && !indexDryRun {
+		return fmt.Errorf("--embed can only be used with --dry-run")
+	}
+
+	catalog, err := loadCatalog(indexNamespace)
+	if err != nil {
+		return err
+	}
+	defer catalog.Close()
+
+	describer, err := tools.NewDescriber(appConfig.Embedding.Template)
+	if err != nil {
+		return err
+	}
+
 	// Create embedding provider
 	embeddingProvider, err := embeddings.NewProvider(*appConfig)
 	if err != nil {
@@ -53,30 +310,130 @@ func indexTools(_ *cobra.Command, _ []string) error {
 
 	repo := db.NewPostgresToolRepository(conn)
 
+	// A plain dry run must not call the embedding API, which the dimension probe may do
+	if !indexDryRun || indexEmbed {
+		if err := checkEmbeddingDimensions(ctx, repo, embeddingProvider); err != nil {
+			return err
+		}
+	}
+
+	stored, err := repo.List(ctx, indexNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to list indexed tools: %w", err)
+	}
+
+	allTools, unavailable, err := catalog.definitions(ctx, cmd.ErrOrStderr())
+	if err != nil {
+		return err
+	}
+	providerName, modelKey := appConfig.Embedding.Provider, embeddingModelKey()
+	entries, err := planIndex(allTools, stored, describer, providerName, modelKey, indexForce)
+	if err != nil {
+		return err
+	}
+	orphaned := orphanedTools(allTools, stored, unavailable)
+
+	var pending []indexEntry
+	counts := map[indexAction]int{}
+	for _, entry := range entries {
+		counts[entry.action]++
+		if entry.action != indexUnchanged {
+			pending = append(pending, entry)
+		}
+	}
+
+	// Each tool to embed contributes its description text followed by its examples
+	var texts []string
+	embedded := 0
+	for _, entry := range pending {
+		if !entry.reembed {
+			continue
+		}
+		embedded++
+		texts = append(texts, entry.description.Text)
+		texts = append(texts, entry.description.Examples...)
+	}
+
+	if indexDryRun {
+		out := cmd.OutOrStdout()
+		if indexNamespace != "" {
+			fmt.Fprintf(out, "Namespace: %s\n", indexNamespace)
+		}
+		writeIndexPlan(out, entries, orphaned, indexPrune)
+
+		if indexEmbed && len(texts) > 0 {
+			if _, err := embeddings.GenerateInBatches(ctx, embeddingProvider, texts); err != nil {
+				return fmt.Errorf("failed to create embeddings: %w", err)
+			}
+			fmt.Fprintf(out, "Embedded %d new and changed tools (%d texts); nothing was written\n", embedded, len(texts))
+		}
+
+		changes := len(pending)
+		if indexPrune {
+			changes += len(orphaned)
+		}
+		if changes > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("dry run: %d pending changes", changes)
+		}
+		if err := unavailableError(cmd, unavailable); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "No pending changes")
+		return nil
+	}
+
+	// Embed in provider-sized batches; results keep the order of texts
+	vectors, err := embeddings.GenerateInBatches(ctx, embeddingProvider, texts)
+	if err != nil {
+		return fmt.Errorf("failed to create embeddings: %w", err)
+	}
+
 	tx, err := conn.Beginx()
 	if err != nil {
 		return fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback()
 
-	allTools := tools.GetAllTools()
-	for _, toolDef := range allTools {
-		toolDescription, err := tools.DescribeTool(toolDef)
-		if err != nil {
-			return fmt.Errorf("could not describe tool %q: %w", toolDef.Name, err)
-		}
-
-		embedding, err := embeddingProvider.GenerateEmbedding(ctx, toolDescription.Text)
-		if err != nil {
-			return fmt.Errorf("failed to create embedding for tool %q: %w", toolDef.Name, err)
+	next := 0
+	for _, entry := range pending {
+		if !entry.reembed {
+			tool := models.NewTool(entry.def, entry.description, pgvector.Vector{})
+			tool.Namespace = indexNamespace
+			if err := repo.UpdateMetadataTx(ctx, tx, tool); err != nil {
+				return fmt.Errorf("failed to update tool %q: %w", entry.def.Name, err)
+			}
+			continue
 		}
 
 		// Convert to pgvector
-		vec := pgvector.NewVector(embedding)
+		vec := pgvector.NewVector(vectors[next])
+		next++
 
-		tool := models.NewTool(toolDef, toolDescription, vec)
+		tool := models.NewTool(entry.def, entry.description, vec)
+		tool.Namespace = indexNamespace
+		tool.EmbeddingProvider = providerName
+		tool.EmbeddingModel = modelKey
 		if err := repo.UpsertTx(ctx, tx, tool); err != nil {
-			return fmt.Errorf("failed to upsert tool %q: %w", toolDef.Name, err)
+			return fmt.Errorf("failed to upsert tool %q: %w", entry.def.Name, err)
+		}
+
+		// Replace even when there are none, so removed examples are deleted
+		examples := make([]*models.ToolEmbedding, len(entry.description.Examples))
+		for i, text := range entry.description.Examples {
+			examples[i] = &models.ToolEmbedding{Text: text, Embedding: pgvector.NewVector(vectors[next])}
+			next++
+		}
+		if err := repo.ReplaceEmbeddingsTx(ctx, tx, tool.ID, models.EmbeddingKindExample, examples); err != nil {
+			return fmt.Errorf("failed to store examples of tool %q: %w", entry.def.Name, err)
+		}
+	}
+
+	if indexPrune {
+		for _, tool := range orphaned {
+			if err := repo.SoftDeleteTx(ctx, tx, indexNamespace, tool.Name); err != nil {
+				return fmt.Errorf("failed to prune tool %q: %w", tool.Name, err)
+			}
 		}
 	}
 
@@ -84,5 +441,135 @@ func indexTools(_ *cobra.Command, _ []string) error {
 		return err
 	}
 
-	return nil
+	// Query embeddings from earlier templates or models would otherwise stay forever
+	if appConfig.Embedding.Cache.Persistent {
+		if err := pruneEmbeddingCache(ctx, conn); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: failed to prune embedding cache: %v\n", err)
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if indexNamespace != "" {
+		fmt.Fprintf(out, "Namespace: %s\n", indexNamespace)
+	}
+	fmt.Fprintf(out, "Indexed tools: %d added, %d updated, %d unchanged\n",
+		counts[indexAdd], counts[indexUpdate], counts[indexUnchanged])
+	switch {
+	case indexPrune && len(orphaned) > 0:
+		fmt.Fprintf(out, "Pruned %d tools that are no longer registered\n", len(orphaned))
+	case len(orphaned) > 0:
+		fmt.Fprintf(out, "%d indexed tools are no longer registered; run with --prune to remove them\n", len(orphaned))
+	}
+
+	return unavailableError(cmd, unavailable)
+}
+
+// listUpstreamTools lists the tools of every upstream server. An upstream that
+// fails is reported on warn and returned in unavailable, keyed by name.
+func listUpstreamTools(ctx context.Context, warn io.Writer, pool *upstream.Pool) ([]tools.ToolDefinition, map[string]bool) {
+	var defs []tools.ToolDefinition
+	unavailable := make(map[string]bool)
+	for _, client := range pool.Clients() {
+		upstreamDefs, err := client.ToolDefinitions(ctx)
+		if err != nil {
+			fmt.Fprintf(warn, "warning: skipping %v\n", err)
+			unavailable[client.Name()] = true
+			continue
+		}
+		defs = append(defs, upstreamDefs...)
+	}
+	return defs, unavailable
+}
+
+// mergeUpstreamTools appends upstream tools to the registered tools. Names must
+// stay unique, or execute_tool could not tell the tools apart.
+func mergeUpstreamTools(registered, upstreamTools []tools.ToolDefinition) ([]tools.ToolDefinition, error) {
+	names := make(map[string]bool, len(registered))
+	for _, def := range registered {
+		names[def.Name] = true
+	}
+	for _, def := range upstreamTools {
+		if names[def.Name] {
+			return nil, fmt.Errorf("upstream tool %q has the same name as a registered tool", def.Name)
+		}
+		names[def.Name] = true
+	}
+	return append(registered, upstreamTools...), nil
+}
+
+func unavailableError(cmd *cobra.Command, unavailable map[string]bool) error {
+	if len(unavailable) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(unavailable))
+	for name := range unavailable {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	cmd.SilenceUsage = true
+	return fmt.Errorf("upstream servers unavailable: %s", strings.Join(names, ", "))
+}
+
+// writeIndexPlan prints the planned index actions as a table, followed by diffs
+// of the descriptions and input schemas of changed tools.
+func writeIndexPlan(out io.Writer, entries []indexEntry, orphaned []*models.Tool, prune bool) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tTOOL\tDETAILS")
+	for _, entry := range entries {
+		switch entry.action {
+		case indexAdd:
+			fmt.Fprintf(tw, "new\t%s\t\n", entry.def.Name)
+		case indexUpdate:
+			details := strings.Join(entry.reasons, ", ")
+			if !entry.reembed {
+				details += " (embeddings kept)"
+			}
+			fmt.Fprintf(tw, "changed\t%s\t%s\n", entry.def.Name, details)
+		default:
+			fmt.Fprintf(tw, "unchanged\t%s\t\n", entry.def.Name)
+		}
+	}
+	for _, tool := range orphaned {
+		details := "kept; run with --prune to remove"
+		if prune {
+			details = "will be pruned"
+		}
+		fmt.Fprintf(tw, "orphaned\t%s\t%s\n", tool.Name, details)
+	}
+	tw.Flush()
+
+	for _, entry := range entries {
+		if entry.action != indexUpdate {
+			continue
+		}
+
+		name := entry.def.Name
+		diffs := []string{
+			textdiff.Unified(
+				name+" description (indexed)", name+" description (registered)",
+				entry.existing.Description, entry.description.Text,
+			),
+			textdiff.Unified(
+				name+" input schema (indexed)", name+" input schema (registered)",
+				normalizeSchema(entry.existing.InputSchema), normalizeSchema(entry.description.InputSchema),
+			),
+			textdiff.Unified(
+				name+" examples (indexed)", name+" examples (registered)",
+				joinLines(entry.existing.Examples), joinLines(entry.description.Examples),
+			),
+		}
+		for _, diff := range diffs {
+			if diff != "" {
+				fmt.Fprintf(out, "\n%s", diff)
+			}
+		}
+	}
+}
+
+// joinLines renders a list one item per line, for diffing.
+func joinLines(items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+	return strings.Join(items, "\n") + "\n"
 }
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ddazal/marcopolo-go/internal/models"
+	"github.com/ddazal/marcopolo-go/internal/tools"
+	"github.com/pgvector/pgvector-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// indexedTool returns the row index stores for def with the default template
+func indexedTool(t *testing.T, def tools.ToolDefinition, provider, model string) *models.Tool {
+	t.Helper()
+	description, err := tools.DescribeTool(def)
+	require.NoError(t, err)
+
+	tool := models.NewTool(def, description, pgvector.Vector{})
+	tool.EmbeddingProvider = provider
+	tool.EmbeddingModel = model
+	return tool
+}
+
+func TestPlanIndex(t *testing.T) {
+	holidays := tools.ToolDefinition{Name: "get_holidays", Description: "List public holidays", Tags: []string{"calendar"}}
+	weather := tools.ToolDefinition{Name: "get_weather", Description: "Current weather"}
+
+	describer, err := tools.NewDescriber("")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		stored      []*models.Tool
+		force       bool
+		wantActions []indexAction
+		wantReembed []bool
+	}{
+		"nothing indexed": {
+			wantActions: []indexAction{indexAdd, indexAdd},
+			wantReembed: []bool{true, true},
+		},
+		"unchanged": {
+			stored: []*models.Tool{
+				indexedTool(t, holidays, "openai", "small"),
+				indexedTool(t, weather, "openai", "small"),
+			},
+			wantActions: []indexAction{indexUnchanged, indexUnchanged},
+			wantReembed: []bool{false, false},
+		},
+		"forced": {
+			stored: []*models.Tool{
+				indexedTool(t, holidays, "openai", "small"),
+				indexedTool(t, weather, "openai", "small"),
+			},
+			force:       true,
+			wantActions: []indexAction{indexUpdate, indexUpdate},
+			wantReembed: []bool{true, true},
+		},
+		"other model": {
+			stored: []*models.Tool{
+				indexedTool(t, holidays, "openai", "large"),
+				indexedTool(t, weather, "openai", "small"),
+			},
+			wantActions: []indexAction{indexUpdate, indexUnchanged},
+			wantReembed: []bool{true, false},
+		},
+		"metadata only": {
+			stored: []*models.Tool{
+				indexedTool(t, tools.ToolDefinition{Name: "get_holidays", Description: "List public holidays"}, "openai", "small"),
+			},
+			wantActions: []indexAction{indexUpdate, indexAdd},
+			wantReembed: []bool{false, true},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			entries, err := planIndex([]tools.ToolDefinition{holidays, weather}, tt.stored, describer, "openai", "small", tt.force)
+			require.NoError(t, err)
+			require.Len(t, entries, 2)
+
+			for i, entry := range entries {
+				assert.Equal(t, tt.wantActions[i], entry.action, entry.def.Name)
+				assert.Equal(t, tt.wantReembed[i], entry.reembed, entry.def.Name)
+			}
+		})
+	}
+}
+
+func TestOrphanedTools(t *testing.T) {
+	defs := []tools.ToolDefinition{{Name: "get_holidays"}, {Name: "github.create_issue", Source: "github"}}
+
+	tests := map[string]struct {
+		stored      []*models.Tool
+		unavailable map[string]bool
+		want        []string
+	}{
+		"all registered": {
+			stored: []*models.Tool{{Name: "get_holidays"}, {Name: "github.create_issue", Source: "github"}},
+		},
+		"local tool removed": {
+			stored: []*models.Tool{{Name: "get_holidays"}, {Name: "get_weather"}},
+			want:   []string{"get_weather"},
+		},
+		"upstream tool removed": {
+			stored: []*models.Tool{{Name: "github.create_issue", Source: "github"}, {Name: "github.close_issue", Source: "github"}},
+			want:   []string{"github.close_issue"},
+		},
+		"tools of an unavailable upstream are kept": {
+			stored: []*models.Tool{
+				{Name: "get_weather"},
+				{Name: "jira.create_ticket", Source: "jira"},
+				{Name: "jira.close_ticket", Source: "jira"},
+			},
+			unavailable: map[string]bool{"jira": true},
+			want:        []string{"get_weather"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var names []string
+			for _, tool := range orphanedTools(defs, tt.stored, tt.unavailable) {
+				names = append(names, tool.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestChangeReasons(t *testing.T) {
+	def := tools.ToolDefinition{
+		Name:        "get_holidays",
+		Description: "List public holidays",
+		Parameters: &tools.Parameters{
+			Properties: map[string]tools.ParameterProperty{"year": {Type: "string"}},
+			Required:   []string{"year"},
+		},
+		Examples: []string{"Is Monday a holiday?"},
+		Tags:     []string{"calendar"},
+	}
+	description, err := tools.DescribeTool(def)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		stored func(tool *models.Tool)
+		want   []string
+	}{
+		"unchanged": {
+			stored: func(*models.Tool) {},
+		},
+		"description": {
+			stored: func(tool *models.Tool) {
+				tool.Description = "Old description"
+				tool.ContentHash = "old"
+			},
+			want: []string{"description"},
+		},
+		"input schema": {
+			stored: func(tool *models.Tool) {
+				schema := `{"properties": {}}`
+				tool.InputSchema = &schema
+				tool.ContentHash = "old"
+			},
+			want: []string{"input schema"},
+		},
+		"examples": {
+			stored: func(tool *models.Tool) {
+				tool.Examples = nil
+				tool.ContentHash = "old"
+			},
+			want: []string{"examples"},
+		},
+		"hash only": {
+			stored: func(tool *models.Tool) { tool.ContentHash = "" },
+			want:   []string{"content hash"},
+		},
+		"metadata": {
+			stored: func(tool *models.Tool) {
+				tool.Tags = models.StringList{"dates"}
+				tool.Owner = "team-a"
+			},
+			want: []string{metadataReason},
+		},
+		"template": {
+			stored: func(tool *models.Tool) { tool.EmbeddingTemplate = "" },
+			want:   []string{"template (none) -> " + description.TemplateVersion},
+		},
+		"model": {
+			stored: func(tool *models.Tool) { tool.EmbeddingModel = "large" },
+			want:   []string{"embedding model openai/large -> openai/small"},
+		},
+		"several": {
+			stored: func(tool *models.Tool) {
+				tool.Description = "Old description"
+				tool.ContentHash = "old"
+				tool.Category = "dates"
+				tool.EmbeddingProvider, tool.EmbeddingModel = "", ""
+			},
+			want: []string{"description", metadataReason, "embedding model (none) -> openai/small"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			stored := indexedTool(t, def, "openai", "small")
+			tt.stored(stored)
+
+			assert.Equal(t, tt.want, changeReasons(stored, def, description, "openai", "small"))
+		})
+	}
+}
+
+func TestMergeUpstreamTools(t *testing.T) {
+	registered := []tools.ToolDefinition{{Name: "get_holidays"}}
+
+	tests := map[string]struct {
+		upstream  []tools.ToolDefinition
+		want      []string
+		wantError string
+	}{
+		"no upstream tools": {
+			want: []string{"get_holidays"},
+		},
+		"appended in order": {
+			upstream: []tools.ToolDefinition{{Name: "github.create_issue"}, {Name: "jira.create_ticket"}},
+			want:     []string{"get_holidays", "github.create_issue", "jira.create_ticket"},
+		},
+		"same name as a registered tool": {
+			upstream:  []tools.ToolDefinition{{Name: "get_holidays"}},
+			wantError: `upstream tool "get_holidays" has the same name as a registered tool`,
+		},
+		"same name in two upstreams": {
+			upstream:  []tools.ToolDefinition{{Name: "search.query"}, {Name: "search.query"}},
+			wantError: `upstream tool "search.query"`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			merged, err := mergeUpstreamTools(slices.Clone(registered), tt.upstream)
+			if tt.wantError != "" {
+				assert.ErrorContains(t, err, tt.wantError)
+				return
+			}
+			require.NoError(t, err)
+
+			var names []string
+			for _, def := range merged {
+				names = append(names, def.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// keysCmd is a command group for managing API keys.
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage API keys for the HTTP transports",
+	Long: `
+Manage the API keys that clients of serve --transport=http|sse authenticate with.
+
+Clients send a key as "Authorization: Bearer <key>" or in an X-API-Key header.
+Each key carries an allowlist of tool names and tags: search_tools only returns
+tools the key allows, and execute_tool refuses the others. Only a hash of each
+key is stored, so a key is shown once, when it is created.`,
+}
+
+func init() {
+	rootCmd.AddCommand(keysCmd)
+
+	keysCmd.AddCommand(keysCreateCmd)
+	keysCmd.AddCommand(keysListCmd)
+	keysCmd.AddCommand(keysRevokeCmd)
+}
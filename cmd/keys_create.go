@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ddazal/marcopolo-go/internal/auth"
+	"github.com/ddazal/marcopolo-go/internal/db"
+	"github.com/ddazal/marcopolo-go/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keysCreateTools     []string
+	keysCreateTags      []string
+	keysCreateNamespace string
+)
+
+var keysCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create an API key",
+	Long: `
+Create an API key and print it. The key cannot be shown again.
+
+The key may use tools whose name matches a --tool pattern, where "*" matches
+any characters, or that have a --tag. At least one is required; use --tool '*'
+to allow every tool.
+
+Clients of the key only see the tools indexed in its --namespace, the default
+namespace unless set. The namespace must be configured under namespaces.
+
+Example:
+  marcopolo-go keys create ci --tool 'github.*' --tag calendar
+  marcopolo-go keys create acme-agent --namespace acme --tool '*'`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(keysCreateTools) == 0 && len(keysCreateTags) == 0 {
+			return fmt.Errorf("set at least one --tool or --tag; use --tool '*' to allow every tool")
+		}
+		// serve rejects keys of namespaces it does not load
+		if _, _, err := namespaceSources(keysCreateNamespace); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+		defer cancel()
+
+		conn, err := openDB(ctx)
+		if err != nil {
+			return fmt.Errorf("connect db: %w", err)
+		}
+		defer conn.Close()
+
+		secret, err := auth.GenerateKey()
+		if err != nil {
+			return fmt.Errorf("generate key: %w", err)
+		}
+
+		key := &models.APIKey{
+			Name:         args[0],
+			Prefix:       auth.DisplayPrefix(secret),
+			KeyHash:      auth.HashKey(secret),
+			Namespace:    keysCreateNamespace,
+			AllowedTools: keysCreateTools,
+			AllowedTags:  keysCreateTags,
+		}
+		if err := db.NewPostgresAPIKeyRepository(conn).Create(ctx, key); err != nil {
+			return fmt.Errorf("create key %q: %w", key.Name, err)
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Created API key %q. Store it now, it cannot be shown again:\n\n", key.Name)
+		fmt.Fprintf(out, "  %s\n", secret)
+		return nil
+	},
+}
+
+func init() {
+	keysCreateCmd.Flags().StringArrayVar(
+		&keysCreateTools,
+		"tool",
+		nil,
+		"Tool name pattern the key may use, '*' matches any characters (repeatable)",
+	)
+	keysCreateCmd.Flags().StringArrayVar(
+		&keysCreateTags,
+		"tag",
+		nil,
+		"Tag of tools the key may use (repeatable)",
+	)
+	keysCreateCmd.Flags().StringVar(
+		&keysCreateNamespace,
+		"namespace",
+		"",
+		"Namespace whose tools the key may use (default: the default namespace)",
+	)
+}
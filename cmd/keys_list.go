@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ddazal/marcopolo-go/internal/db"
+	"github.com/spf13/cobra"
+)
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List API keys",
+	Long:  "Print every API key, including revoked ones, with its namespace, allowlist and last use.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+		defer cancel()
+
+		conn, err := openDB(ctx)
+		if err != nil {
+			return fmt.Errorf("connect db: %w", err)
+		}
+		defer conn.Close()
+
+		keys, err := db.NewPostgresAPIKeyRepository(conn).List(ctx)
+		if err != nil {
+			return fmt.Errorf("list keys: %w", err)
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tPREFIX\tNAMESPACE\tTOOLS\tTAGS\tCREATED_AT\tLAST_USED_AT\tSTATE")
+
+		for _, key := range keys {
+			lastUsed := "-"
+			if key.LastUsedAt != nil {
+				lastUsed = key.LastUsedAt.Format(time.RFC3339)
+			}
+			state := "active"
+			if key.RevokedAt != nil {
+				state = "revoked " + key.RevokedAt.Format(time.RFC3339)
+			}
+
+			fmt.Fprintf(
+				w,
+				"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				key.Name,
+				key.Prefix,
+				orDash(key.Namespace),
+				listOrDash(key.AllowedTools),
+				listOrDash(key.AllowedTags),
+				key.CreatedAt.Format(time.RFC3339),
+				lastUsed,
+				state,
+			)
+		}
+
+		return w.Flush()
+	},
+}
+
+func listOrDash(items []string) string {
+	return orDash(strings.Join(items, ","))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ddazal/marcopolo-go/internal/db"
+	"github.com/spf13/cobra"
+)
+
+var keysRevokeCmd = &cobra.Command{
+	Use:   "revoke <name>",
+	Short: "Revoke an API key",
+	Long:  "Revoke the active API key with the given name. Requests with it are rejected right away.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+		defer cancel()
+
+		conn, err := openDB(ctx)
+		if err != nil {
+			return fmt.Errorf("connect db: %w", err)
+		}
+		defer conn.Close()
+
+		if err := db.NewPostgresAPIKeyRepository(conn).Revoke(ctx, args[0]); err != nil {
+			return fmt.Errorf("revoke key: %w", err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Revoked API key %q\n", args[0])
+		return nil
+	},
+}
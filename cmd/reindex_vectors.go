@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ddazal/marcopolo-go/internal/db"
+	"github.com/spf13/cobra"
+)
+
+// reindexVectorsCmd represents the reindex-vectors command
+var reindexVectorsCmd = &cobra.Command{
+	Use:   "reindex-vectors",
+	Short: "Rebuild the HNSW indexes on tool embeddings",
+	Long: `Rebuild the HNSW indexes used for vector search on tools.embedding and on
+the example embeddings in tool_embeddings.embedding.
+
+Run this after bulk loads, or after changing the index parameters in config.yaml:
+  search:
+    hnsw:
+      m: 16
+      ef_construction: 64
+
+The replacement index is built concurrently, so search keeps working on the
+old indexes until the new ones are swapped in.`,
+	RunE: reindexVectors,
+}
+
+func init() {
+	rootCmd.AddCommand(reindexVectorsCmd)
+}
+
+func reindexVectors(cmd *cobra.Command, _ []string) error {
+	ctx := context.Background()
+
+	params := db.HNSWParams{
+		M:              appConfig.Search.HNSW.M,
+		EFConstruction: appConfig.Search.HNSW.EFConstruction,
+	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	conn, err := openDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	repo := db.NewPostgresToolRepository(conn)
+
+	start := time.Now()
+	if err := repo.RebuildEmbeddingIndex(ctx, params); err != nil {
+		return fmt.Errorf("failed to rebuild index %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Rebuilt %s and %s (m=%d, ef_construction=%d) in %s\n",
+		db.EmbeddingIndexName, db.ExampleEmbeddingIndexName, params.M, params.EFConstruction, time.Since(start).Round(time.Millisecond))
+
+	return nil
+}
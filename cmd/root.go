@@ -8,6 +8,7 @@ import (
 
 	"github.com/ddazal/marcopolo-go/internal/config"
 	"github.com/ddazal/marcopolo-go/internal/db"
+	"github.com/ddazal/marcopolo-go/internal/embeddings"
 	"github.com/jmoiron/sqlx"
 	"github.com/spf13/cobra"
 )
@@ -50,3 +51,44 @@ func openDB(ctx context.Context) (*sqlx.DB, error) {
 	}
 	return db.Connect(ctx, *appConfig)
 }
+
+// embeddingModelKey identifies the configured embedding model. Shortened embeddings
+// differ from full-size ones, so a configured size is part of the key.
+func embeddingModelKey() string {
+	if appConfig.Embedding.Dimensions > 0 {
+		return fmt.Sprintf("%s@%d", appConfig.Embedding.Model, appConfig.Embedding.Dimensions)
+	}
+	return appConfig.Embedding.Model
+}
+
+// checkEmbeddingDimensions verifies that the provider's vectors fit the tools.embedding and
+// tool_embeddings.embedding columns, which must have the same width.
+// Providers that only learn their size from a response are probed with a short text.
+func checkEmbeddingDimensions(ctx context.Context, repo db.ToolRepository, provider embeddings.Provider) error {
+	columnDims, err := repo.EmbeddingDimensions(ctx)
+	if err != nil {
+		return err
+	}
+	if columnDims == 0 {
+		return nil
+	}
+
+	providerDims := provider.GetDimensions()
+	if providerDims == 0 {
+		probe, err := provider.GenerateEmbedding(ctx, "dimension check")
+		if err != nil {
+			return fmt.Errorf("failed to probe embedding dimensions: %w", err)
+		}
+		providerDims = len(probe)
+	}
+
+	if providerDims != columnDims {
+		return fmt.Errorf(
+			"embedding dimension mismatch: provider %q with model %q produces %d dimensions but tools.embedding is vector(%d); "+
+				"set embedding.dimensions to %d if the model supports it, or migrate tools.embedding and tool_embeddings.embedding to vector(%d) and re-run index",
+			appConfig.Embedding.Provider, appConfig.Embedding.Model, providerDims, columnDims, columnDims, providerDims,
+		)
+	}
+
+	return nil
+}
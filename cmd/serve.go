@@ -3,10 +3,18 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/ddazal/marcopolo-go/internal/auth"
 	"github.com/ddazal/marcopolo-go/internal/db"
 	"github.com/ddazal/marcopolo-go/internal/embeddings"
 	"github.com/ddazal/marcopolo-go/internal/mcp"
+	"github.com/ddazal/marcopolo-go/internal/rerank"
+	"github.com/jmoiron/sqlx"
 	"github.com/pgvector/pgvector-go"
 	"github.com/spf13/cobra"
 )
@@ -17,10 +25,14 @@ var serveCmd = &cobra.Command{
 	Short: "Start MCP server with tool search and execution",
 	Long: `Starts an MCP (Model Context Protocol) server that exposes tools for:
 
-- Searching available tools using semantic similarity
-- Executing tools with provided parameters
+- Searching available tools using semantic similarity, full-text matching or both
+- Executing tools with provided parameters, proxying tools of upstream MCP
+  servers to the server that provides them
 
-The server communicates over stdio and can be used with MCP-compatible clients.
+The server communicates over stdio by default, serving the client that started
+it. With --transport=http (streamable HTTP at /mcp) or --transport=sse (SSE at
+/sse), one long-running server listens on --addr and serves many clients,
+sharing its database pool, embedding client and caches.
 
 Configuration:
 - Database connection for tool search
@@ -29,13 +41,49 @@ Configuration:
 
 Example usage:
   marcopolo-go serve
+  marcopolo-go serve --transport=http --addr=:8080
 
-The server will start and listen for MCP requests on stdin/stdout.`,
+The transport and address can also be set with server.transport and
+server.addr in config.yaml. HTTP clients must send an API key created with
+"keys create" unless server.require_api_key is false; each key only sees and
+executes the tools it allows.
+
+Each session sees the tools of one namespace: the namespace of its API key, or
+the one named by an X-Marcopolo-Namespace header, or --namespace
+(server.namespace) for stdio and other clients.
+
+On SIGINT or SIGTERM the server stops accepting clients and gives open
+requests server.shutdown_timeout to finish.`,
 	RunE: runServe,
 }
 
+var (
+	serveTransport string
+	serveAddr      string
+	serveNamespace string
+)
+
 func init() {
 	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(
+		&serveTransport,
+		"transport",
+		"",
+		"Transport to serve: stdio, http or sse (default: server.transport, stdio)",
+	)
+	serveCmd.Flags().StringVar(
+		&serveAddr,
+		"addr",
+		"",
+		"Listen address of the http and sse transports (default: server.addr, :8080)",
+	)
+	serveCmd.Flags().StringVar(
+		&serveNamespace,
+		"namespace",
+		"",
+		"Namespace of stdio clients and of HTTP clients without a key or namespace header (default: server.namespace)",
+	)
 }
 
 // toolRepositoryAdapter adapts db.ToolRepository to mcp.ToolRepository
@@ -43,13 +91,40 @@ type toolRepositoryAdapter struct {
 	repo db.ToolRepository
 }
 
-func (a *toolRepositoryAdapter) FindSimilarWithScore(ctx context.Context, embedding pgvector.Vector, minScore float64, limit int) ([]*mcp.ToolWithScore, error) {
-	dbTools, err := a.repo.FindSimilarWithScore(ctx, embedding, minScore, limit)
+func (a *toolRepositoryAdapter) FindSimilarWithScore(ctx context.Context, embedding pgvector.Vector, minScore float64, limit int, filter mcp.ToolFilter) ([]*mcp.ToolWithScore, error) {
+	dbTools, err := a.repo.FindSimilarWithScore(ctx, embedding, minScore, limit, db.ToolFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+	return toMCPTools(dbTools), nil
+}
+
+func (a *toolRepositoryAdapter) FindLexicalWithScore(ctx context.Context, query string, limit int, filter mcp.ToolFilter) ([]*mcp.ToolWithScore, error) {
+	dbTools, err := a.repo.FindLexicalWithScore(ctx, query, limit, db.ToolFilter(filter))
 	if err != nil {
 		return nil, err
 	}
+	return toMCPTools(dbTools), nil
+}
+
+func (a *toolRepositoryAdapter) FindHybridWithScore(ctx context.Context, query string, embedding pgvector.Vector, minScore float64, limit int, filter mcp.ToolFilter) ([]*mcp.ToolWithScore, error) {
+	dbTools, err := a.repo.FindHybridWithScore(ctx, query, embedding, minScore, limit, db.ToolFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+	return toMCPTools(dbTools), nil
+}
+
+func (a *toolRepositoryAdapter) GetTool(ctx context.Context, namespace, name string) (*mcp.IndexedTool, bool, error) {
+	tool, found, err := a.repo.Get(ctx, namespace, name)
+	if err != nil || !found {
+		return nil, found, err
+	}
+	return &mcp.IndexedTool{Tags: tool.Tags, InputSchema: tool.InputSchema}, true, nil
+}
 
-	// Convert db.ToolWithScore to mcp.ToolWithScore
+// toMCPTools converts db.ToolWithScore to mcp.ToolWithScore
+func toMCPTools(dbTools []*db.ToolWithScore) []*mcp.ToolWithScore {
 	mcpTools := make([]*mcp.ToolWithScore, len(dbTools))
 	for i, dbTool := range dbTools {
 		mcpTools[i] = &mcp.ToolWithScore{
@@ -57,15 +132,138 @@ func (a *toolRepositoryAdapter) FindSimilarWithScore(ctx context.Context, embedd
 			Name:           dbTool.Name,
 			Description:    dbTool.Description,
 			InputSchema:    dbTool.InputSchema,
+			Embedding:      dbTool.Embedding,
+			Tags:           dbTool.Tags,
+			Category:       dbTool.Category,
+			Owner:          dbTool.Owner,
 			RelevanceScore: dbTool.RelevanceScore,
+			MatchedExample: dbTool.MatchedExample,
 		}
 	}
 
-	return mcpTools, nil
+	return mcpTools
 }
 
-func runServe(_ *cobra.Command, _ []string) error {
-	ctx := context.Background()
+// apiKeyStoreAdapter adapts db.APIKeyRepository to auth.KeyStore
+type apiKeyStoreAdapter struct {
+	repo db.APIKeyRepository
+}
+
+func (a *apiKeyStoreAdapter) Lookup(ctx context.Context, keyHash string) (*auth.Access, bool, error) {
+	key, found, err := a.repo.Authenticate(ctx, keyHash)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	return &auth.Access{KeyName: key.Name, Namespace: key.Namespace, Tools: key.AllowedTools, Tags: key.AllowedTags}, true, nil
+}
+
+// embeddingCacheAdapter adapts db.EmbeddingCacheRepository to embeddings.CacheStore
+type embeddingCacheAdapter struct {
+	repo db.EmbeddingCacheRepository
+}
+
+func (a *embeddingCacheAdapter) GetEmbedding(ctx context.Context, key embeddings.CacheKey) ([]float32, bool, error) {
+	vec, found, err := a.repo.Get(ctx, key.Provider, key.Model, key.TextHash)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	return vec.Slice(), true, nil
+}
+
+func (a *embeddingCacheAdapter) PutEmbedding(ctx context.Context, key embeddings.CacheKey, embedding []float32) error {
+	return a.repo.Put(ctx, key.Provider, key.Model, key.TextHash, pgvector.NewVector(embedding))
+}
+
+// newQueryEmbeddingCache wraps the provider with the configured query cache.
+func newQueryEmbeddingCache(conn *sqlx.DB, provider embeddings.Provider) *embeddings.CachedProvider {
+	cfg := appConfig.Embedding
+
+	var store embeddings.CacheStore
+	if cfg.Cache.Persistent {
+		store = &embeddingCacheAdapter{repo: db.NewPostgresEmbeddingCacheRepository(conn)}
+	}
+
+	return embeddings.NewCachedProvider(provider, cfg.Provider, embeddingModelKey(), cfg.Cache.Size, cfg.Cache.TTL, store)
+}
+
+// pruneEmbeddingCache applies the persistent cache limits to the embedding_cache table.
+func pruneEmbeddingCache(ctx context.Context, conn *sqlx.DB) error {
+	cfg := appConfig.Embedding.Cache
+	deleted, err := db.NewPostgresEmbeddingCacheRepository(conn).Prune(ctx, cfg.PersistentTTL, cfg.PersistentMaxEntries)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("pruned %d embedding cache entries", deleted)
+	}
+	return nil
+}
+
+func logCacheStats(cached *embeddings.CachedProvider) {
+	stats := cached.Stats()
+	log.Printf("query embedding cache: %d hits, %d store hits, %d misses, %d store errors",
+		stats.Hits, stats.StoreHits, stats.Misses, stats.StoreErrors)
+}
+
+// watchCacheStats logs the query cache counters every interval until ctx is
+// done, skipping intervals in which they did not change.
+func watchCacheStats(ctx context.Context, cached *embeddings.CachedProvider, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last embeddings.CacheStats
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if stats := cached.Stats(); stats != last {
+				last = stats
+				logCacheStats(cached)
+			}
+		}
+	}
+}
+
+func runServe(cmd *cobra.Command, _ []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := mcp.ServeOptions{
+		Transport:       mcp.Transport(appConfig.Server.Transport),
+		Addr:            appConfig.Server.Addr,
+		BaseURL:         appConfig.Server.BaseURL,
+		Namespace:       appConfig.Server.Namespace,
+		ShutdownTimeout: appConfig.Server.ShutdownTimeout,
+	}
+	if serveTransport != "" {
+		opts.Transport = mcp.Transport(serveTransport)
+	}
+	if serveAddr != "" {
+		opts.Addr = serveAddr
+	}
+	if cmd.Flags().Changed("namespace") {
+		opts.Namespace = serveNamespace
+	}
+	if !opts.Transport.Valid() {
+		return fmt.Errorf("invalid transport %q: must be one of %v", opts.Transport, mcp.Transports)
+	}
+	if _, _, err := namespaceSources(opts.Namespace); err != nil {
+		return err
+	}
+
+	// Upstream sessions are opened on the first call to one of their tools
+	catalogs, err := loadCatalogs()
+	if err != nil {
+		return err
+	}
+	defer closeCatalogs(catalogs)
+	for name := range catalogs {
+		opts.Namespaces = append(opts.Namespaces, name)
+	}
 
 	conn, err := openDB(ctx)
 	if err != nil {
@@ -79,13 +277,50 @@ func runServe(_ *cobra.Command, _ []string) error {
 	}
 
 	// Create repository and adapt it
-	dbRepo := db.NewPostgresToolRepository(conn)
+	dbRepo := db.NewPostgresToolRepository(conn).WithSearchOptions(db.SearchOptions{
+		EFSearch: appConfig.Search.HNSW.EFSearch,
+	})
+	if err := checkEmbeddingDimensions(ctx, dbRepo, embProvider); err != nil {
+		return err
+	}
 	mcpRepo := &toolRepositoryAdapter{repo: dbRepo}
 
+	searchMode := mcp.SearchMode(appConfig.Search.Mode)
+	if !searchMode.Valid() {
+		return fmt.Errorf("invalid search.mode %q: must be one of %v", searchMode, mcp.SearchModes)
+	}
+
+	var queryEmbedder mcp.EmbeddingProvider = embProvider
+	if appConfig.Embedding.Cache.Enabled {
+		if appConfig.Embedding.Cache.Persistent {
+			if err := pruneEmbeddingCache(ctx, conn); err != nil {
+				log.Printf("failed to prune embedding cache: %v", err)
+			}
+		}
+
+		cached := newQueryEmbeddingCache(conn, embProvider)
+		go watchCacheStats(ctx, cached, appConfig.Embedding.Cache.StatsInterval)
+		defer logCacheStats(cached)
+		queryEmbedder = cached
+	}
+
+	reranker, err := rerank.NewReranker(appConfig.Search.Rerank)
+	if err != nil {
+		return fmt.Errorf("failed to create reranker: %w", err)
+	}
+
+	if appConfig.Server.RequireAPIKey {
+		opts.KeyStore = &apiKeyStoreAdapter{repo: db.NewPostgresAPIKeyRepository(conn)}
+	}
+
 	server := mcp.NewServer(&mcp.ServerDependencies{
 		ToolRepo:          mcpRepo,
-		EmbeddingProvider: embProvider,
+		EmbeddingProvider: queryEmbedder,
+		DefaultSearchMode: searchMode,
+		Reranker:          reranker,
+		RerankCandidates:  appConfig.Search.Rerank.Candidates,
+		ToolProxy:         namespaceProxy(catalogs),
 	})
 
-	return server.Serve(ctx)
+	return server.Serve(ctx, opts)
 }
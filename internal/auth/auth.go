@@ -0,0 +1,114 @@
+// Package auth authenticates clients of the HTTP transports with API keys and
+// carries each client's tool allowlist through request contexts.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"slices"
+	"strings"
+)
+
+// KeyPrefix starts every generated key, so keys are easy to recognize in configs and logs
+const KeyPrefix = "mp_"
+
+// displayLength is how many characters of a key are kept for display
+const displayLength = len(KeyPrefix) + 8
+
+// GenerateKey returns a new random API key.
+func GenerateKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return KeyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// HashKey returns the hex SHA-256 of a key, as stored in the database. Keys are
+// random, so a fast hash is enough.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// DisplayPrefix returns the start of a key, which identifies it without revealing it.
+func DisplayPrefix(key string) string {
+	if len(key) <= displayLength {
+		return key
+	}
+	return key[:displayLength]
+}
+
+// Access is what an authenticated client may use: tools whose name matches one
+// of Tools, where "*" matches any characters, or that have one of Tags, within
+// the key's namespace.
+type Access struct {
+	KeyName   string
+	Namespace string
+	Tools     []string
+	Tags      []string
+}
+
+// AllowsName reports whether the tool name matches one of the allowed patterns.
+func (a *Access) AllowsName(name string) bool {
+	for _, pattern := range a.Tools {
+		if MatchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows reports whether a tool with the given name and tags may be used.
+func (a *Access) Allows(name string, tags []string) bool {
+	if a.AllowsName(name) {
+		return true
+	}
+	for _, tag := range tags {
+		if slices.Contains(a.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPattern reports whether name matches pattern, where "*" matches any
+// characters and every other character matches itself.
+func MatchPattern(pattern, name string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == name
+	}
+
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(name, part)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(part):]
+	}
+	return strings.HasSuffix(name, last)
+}
+
+type accessKey struct{}
+
+// WithAccess returns a context that carries the client's access.
+func WithAccess(ctx context.Context, access *Access) context.Context {
+	return context.WithValue(ctx, accessKey{}, access)
+}
+
+// FromContext returns the client's access. ok is false when the request was not
+// authenticated, such as over stdio, and every tool may be used.
+func FromContext(ctx context.Context) (access *Access, ok bool) {
+	access, ok = ctx.Value(accessKey{}).(*Access)
+	return access, ok && access != nil
+}
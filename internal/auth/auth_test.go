@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateKey(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, KeyPrefix))
+	assert.Len(t, key, len(KeyPrefix)+43)
+
+	other, err := GenerateKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+	assert.NotEqual(t, HashKey(key), HashKey(other))
+	assert.Equal(t, HashKey(key), HashKey(key))
+
+	assert.Equal(t, key[:len(KeyPrefix)+8], DisplayPrefix(key))
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := map[string]struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		"exact":                  {pattern: "get_holidays", name: "get_holidays", want: true},
+		"exact mismatch":         {pattern: "get_holidays", name: "get_holidays_v1", want: false},
+		"star matches all":       {pattern: "*", name: "github.create_issue", want: true},
+		"prefix":                 {pattern: "github.*", name: "github.create_issue", want: true},
+		"prefix mismatch":        {pattern: "github.*", name: "gitlab.create_issue", want: false},
+		"suffix":                 {pattern: "*_v1", name: "get_holidays_v1", want: true},
+		"middle":                 {pattern: "get_*_v*", name: "get_holidays_v2", want: true},
+		"middle mismatch":        {pattern: "get_*_v*", name: "get_holidays", want: false},
+		"prefix and suffix":      {pattern: "ab*ba", name: "aba", want: false},
+		"star matches empty":     {pattern: "get_*holidays", name: "get_holidays", want: true},
+		"other characters exact": {pattern: "get_?", name: "get_a", want: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchPattern(tt.pattern, tt.name))
+		})
+	}
+}
+
+func TestAccessAllows(t *testing.T) {
+	access := &Access{Tools: []string{"github.*"}, Tags: []string{"calendar"}}
+
+	assert.True(t, access.Allows("github.create_issue", nil))
+	assert.True(t, access.Allows("get_holidays", []string{"holidays", "calendar"}))
+	assert.False(t, access.Allows("convert_currency", []string{"finance"}))
+	assert.False(t, (&Access{}).Allows("get_holidays", []string{"calendar"}))
+}
+
+type stubKeyStore map[string]*Access
+
+func (s stubKeyStore) Lookup(_ context.Context, keyHash string) (*Access, bool, error) {
+	if keyHash == HashKey("broken") {
+		return nil, false, errors.New("database is down")
+	}
+	access, ok := s[keyHash]
+	return access, ok, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	store := stubKeyStore{HashKey("mp_valid"): {KeyName: "ci", Tools: []string{"*"}}}
+	handler := Middleware(store, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		access, ok := FromContext(r.Context())
+		require.True(t, ok)
+		w.Write([]byte(access.KeyName))
+	}))
+
+	tests := map[string]struct {
+		headers    map[string]string
+		wantStatus int
+		wantBody   string
+	}{
+		"bearer token": {
+			headers:    map[string]string{"Authorization": "Bearer mp_valid"},
+			wantStatus: http.StatusOK,
+			wantBody:   "ci",
+		},
+		"api key header": {
+			headers:    map[string]string{"X-API-Key": "mp_valid"},
+			wantStatus: http.StatusOK,
+			wantBody:   "ci",
+		},
+		"missing key": {
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "missing API key",
+		},
+		"unknown key": {
+			headers:    map[string]string{"Authorization": "Bearer mp_unknown"},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "invalid API key",
+		},
+		"other scheme": {
+			headers:    map[string]string{"Authorization": "Basic bXA6dmFsaWQ="},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "missing API key",
+		},
+		"store error": {
+			headers:    map[string]string{"X-API-Key": "broken"},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "failed to check API key",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	access := &Access{KeyName: "ci"}
+	got, ok := FromContext(WithAccess(context.Background(), access))
+	assert.True(t, ok)
+	assert.Same(t, access, got)
+}
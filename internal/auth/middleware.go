@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// KeyStore looks up API keys by hash.
+type KeyStore interface {
+	// Lookup returns the access of the active key with the given hash.
+	// found is false for unknown and revoked keys.
+	Lookup(ctx context.Context, keyHash string) (access *Access, found bool, err error)
+}
+
+// Middleware rejects requests without a valid API key with 401 and adds the
+// key's access to the context of the others. The key is read from an
+// "Authorization: Bearer <key>" header, or from an X-API-Key header.
+func Middleware(store KeyStore, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := requestKey(r)
+		if key == "" {
+			unauthorized(w, "missing API key")
+			return
+		}
+
+		access, found, err := store.Lookup(r.Context(), HashKey(key))
+		if err != nil {
+			log.Printf("failed to look up API key %s: %v", DisplayPrefix(key), err)
+			http.Error(w, "failed to check API key", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			unauthorized(w, "invalid API key")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithAccess(r.Context(), access)))
+	})
+}
+
+func requestKey(r *http.Request) string {
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="marcopolo"`)
+	http.Error(w, message, http.StatusUnauthorized)
+}
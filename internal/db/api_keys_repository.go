@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ddazal/marcopolo-go/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrAPIKeyNotFound is returned when an active API key targeted by name does not exist.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKeyRepository defines the interface for api_keys table operations.
+type APIKeyRepository interface {
+	// Create inserts a key and sets its ID and CreatedAt.
+	// Fails if an active key already uses the name.
+	Create(ctx context.Context, key *models.APIKey) error
+
+	// List returns all keys, including revoked ones, ordered by creation.
+	List(ctx context.Context) ([]*models.APIKey, error)
+
+	// Revoke revokes the active key with the given name.
+	// Returns ErrAPIKeyNotFound if there is no active key with that name.
+	Revoke(ctx context.Context, name string) error
+
+	// Authenticate returns the active key with the given hash and records its use,
+	// at most once a minute.
+	Authenticate(ctx context.Context, keyHash string) (*models.APIKey, bool, error)
+}
+
+// apiKeyTouchInterval is the precision of last_used_at
+const apiKeyTouchInterval = time.Minute
+
+// PostgresAPIKeyRepository implements APIKeyRepository using PostgreSQL.
+type PostgresAPIKeyRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresAPIKeyRepository creates a new PostgreSQL-backed API key repository.
+func NewPostgresAPIKeyRepository(db *sqlx.DB) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{db: db}
+}
+
+// Create inserts a key and sets its ID and CreatedAt.
+func (r *PostgresAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, namespace, allowed_tools, allowed_tags)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		key.Namespace,
+		key.AllowedTools,
+		key.AllowedTags,
+	).Scan(&key.ID, &key.CreatedAt)
+}
+
+// List returns all keys, including revoked ones, ordered by creation.
+func (r *PostgresAPIKeyRepository) List(ctx context.Context) ([]*models.APIKey, error) {
+	query := `
+		SELECT id, created_at, name, prefix, key_hash, namespace, allowed_tools, allowed_tags, last_used_at, revoked_at
+		FROM api_keys
+		ORDER BY created_at, id
+	`
+
+	var results []*models.APIKey
+	if err := r.db.SelectContext(ctx, &results, query); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Revoke revokes the active key with the given name.
+func (r *PostgresAPIKeyRepository) Revoke(ctx context.Context, name string) error {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = now()
+		WHERE name = $1 AND revoked_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, name)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", ErrAPIKeyNotFound, name)
+	}
+	return nil
+}
+
+// Authenticate returns the active key with the given hash and sets its last_used_at.
+// last_used_at is only written when it is older than apiKeyTouchInterval, so
+// authenticated requests are reads.
+func (r *PostgresAPIKeyRepository) Authenticate(ctx context.Context, keyHash string) (*models.APIKey, bool, error) {
+	query := `
+		SELECT id, created_at, name, prefix, key_hash, namespace, allowed_tools, allowed_tags, last_used_at, revoked_at
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`
+
+	var key models.APIKey
+	err := r.db.GetContext(ctx, &key, query, keyHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > apiKeyTouchInterval {
+		// The condition is repeated so concurrent requests write once
+		touch := `
+			UPDATE api_keys
+			SET last_used_at = now()
+			WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - make_interval(secs => $2))
+			RETURNING last_used_at
+		`
+		err := r.db.QueryRowContext(ctx, touch, key.ID, apiKeyTouchInterval.Seconds()).Scan(&key.LastUsedAt)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, false, err
+		}
+	}
+
+	return &key, true, nil
+}
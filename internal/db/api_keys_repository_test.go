@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ddazal/marcopolo-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresAPIKeyRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewPostgresAPIKeyRepository(db)
+	ctx := context.Background()
+
+	key := &models.APIKey{
+		Name:         "ci",
+		Prefix:       "mp_abcdefgh",
+		KeyHash:      "hash-1",
+		AllowedTools: models.StringList{"get_*"},
+		AllowedTags:  models.StringList{"calendar"},
+	}
+	require.NoError(t, repo.Create(ctx, key))
+	assert.NotZero(t, key.ID)
+
+	// Names of active keys are unique
+	err := repo.Create(ctx, &models.APIKey{Name: "ci", Prefix: "mp_other", KeyHash: "hash-2"})
+	assert.Error(t, err)
+
+	found, ok, err := repo.Authenticate(ctx, "hash-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "ci", found.Name)
+	assert.Equal(t, models.StringList{"get_*"}, found.AllowedTools)
+	assert.Equal(t, models.StringList{"calendar"}, found.AllowedTags)
+	require.NotNil(t, found.LastUsedAt)
+
+	// Within a minute, last_used_at is not rewritten
+	again, ok, err := repo.Authenticate(ctx, "hash-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, *found.LastUsedAt, *again.LastUsedAt)
+
+	_, err = db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = now() - interval '1 hour' WHERE key_hash = 'hash-1'`)
+	require.NoError(t, err)
+	again, _, err = repo.Authenticate(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.True(t, again.LastUsedAt.After(found.LastUsedAt.Add(-time.Minute)))
+
+	_, ok, err = repo.Authenticate(ctx, "unknown")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, repo.Revoke(ctx, "ci"))
+	assert.ErrorIs(t, repo.Revoke(ctx, "ci"), ErrAPIKeyNotFound)
+
+	_, ok, err = repo.Authenticate(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.False(t, ok, "revoked keys do not authenticate")
+
+	// A revoked key's name can be reused
+	require.NoError(t, repo.Create(ctx, &models.APIKey{Name: "ci", Prefix: "mp_new", KeyHash: "hash-3"}))
+
+	keys, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.NotNil(t, keys[0].RevokedAt)
+	assert.Nil(t, keys[1].RevokedAt)
+}
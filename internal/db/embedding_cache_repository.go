@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pgvector/pgvector-go"
+)
+
+// EmbeddingCacheRepository defines the interface for embedding_cache table operations.
+type EmbeddingCacheRepository interface {
+	// Get returns the cached embedding for the key, if present.
+	Get(ctx context.Context, provider, model, textHash string) (pgvector.Vector, bool, error)
+
+	// Put stores an embedding, replacing any existing entry for the key.
+	Put(ctx context.Context, provider, model, textHash string, embedding pgvector.Vector) error
+
+	// Prune deletes entries unused for longer than maxAge, then the least recently
+	// used entries beyond maxEntries. Zero disables the corresponding limit.
+	// Returns the number of deleted entries.
+	Prune(ctx context.Context, maxAge time.Duration, maxEntries int) (int64, error)
+}
+
+// cacheTouchInterval bounds how often a hit updates an entry's last_used_at, so
+// that hot entries do not cost a write per lookup
+const cacheTouchInterval = time.Minute
+
+// PostgresEmbeddingCacheRepository implements EmbeddingCacheRepository using PostgreSQL.
+type PostgresEmbeddingCacheRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresEmbeddingCacheRepository creates a new PostgreSQL-backed embedding cache.
+func NewPostgresEmbeddingCacheRepository(db *sqlx.DB) *PostgresEmbeddingCacheRepository {
+	return &PostgresEmbeddingCacheRepository{db: db}
+}
+
+// Get returns the cached embedding for the key, if present, and records its use.
+func (r *PostgresEmbeddingCacheRepository) Get(ctx context.Context, provider, model, textHash string) (pgvector.Vector, bool, error) {
+	query := `
+		SELECT embedding, last_used_at < now() - make_interval(secs => $4) AS stale
+		FROM embedding_cache
+		WHERE provider = $1 AND model = $2 AND text_hash = $3
+	`
+
+	var embedding pgvector.Vector
+	var stale bool
+	err := r.db.QueryRowContext(ctx, query, provider, model, textHash, cacheTouchInterval.Seconds()).Scan(&embedding, &stale)
+	if errors.Is(err, sql.ErrNoRows) {
+		return pgvector.Vector{}, false, nil
+	}
+	if err != nil {
+		return pgvector.Vector{}, false, err
+	}
+
+	if stale {
+		touch := `
+			UPDATE embedding_cache
+			SET last_used_at = now()
+			WHERE provider = $1 AND model = $2 AND text_hash = $3
+		`
+		if _, err := r.db.ExecContext(ctx, touch, provider, model, textHash); err != nil {
+			return pgvector.Vector{}, false, err
+		}
+	}
+
+	return embedding, true, nil
+}
+
+// Put stores an embedding, replacing any existing entry for the key.
+func (r *PostgresEmbeddingCacheRepository) Put(ctx context.Context, provider, model, textHash string, embedding pgvector.Vector) error {
+	query := `
+		INSERT INTO embedding_cache (provider, model, text_hash, embedding)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, model, text_hash) DO UPDATE SET
+			embedding = EXCLUDED.embedding,
+			created_at = now(),
+			last_used_at = now()
+	`
+
+	_, err := r.db.ExecContext(ctx, query, provider, model, textHash, embedding)
+	return err
+}
+
+// Prune deletes stale entries, then the least recently used ones beyond maxEntries.
+func (r *PostgresEmbeddingCacheRepository) Prune(ctx context.Context, maxAge time.Duration, maxEntries int) (int64, error) {
+	var deleted int64
+
+	if maxAge > 0 {
+		result, err := r.db.ExecContext(ctx,
+			`DELETE FROM embedding_cache WHERE last_used_at < now() - make_interval(secs => $1)`,
+			maxAge.Seconds(),
+		)
+		if err != nil {
+			return deleted, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+
+	if maxEntries > 0 {
+		result, err := r.db.ExecContext(ctx, `
+			DELETE FROM embedding_cache
+			WHERE (provider, model, text_hash) IN (
+				SELECT provider, model, text_hash
+				FROM embedding_cache
+				ORDER BY last_used_at DESC
+				OFFSET $1
+			)
+		`, maxEntries)
+		if err != nil {
+			return deleted, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+
+	return deleted, nil
+}
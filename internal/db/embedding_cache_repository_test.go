@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pgvector/pgvector-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresEmbeddingCacheRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewPostgresEmbeddingCacheRepository(db)
+	ctx := context.Background()
+
+	_, found, err := repo.Get(ctx, "openai", "text-embedding-3-small", "abc")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, repo.Put(ctx, "openai", "text-embedding-3-small", "abc", pgvector.NewVector([]float32{1, 2, 3})))
+	require.NoError(t, repo.Put(ctx, "openai", "text-embedding-3-small", "abc", pgvector.NewVector([]float32{4, 5, 6})))
+
+	embedding, found, err := repo.Get(ctx, "openai", "text-embedding-3-small", "abc")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []float32{4, 5, 6}, embedding.Slice())
+
+	// Different model does not share entries
+	_, found, err = repo.Get(ctx, "openai", "text-embedding-3-large", "abc")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestPostgresEmbeddingCacheRepositoryPrune(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewPostgresEmbeddingCacheRepository(db)
+	ctx := context.Background()
+
+	for _, hash := range []string{"old", "a", "b", "c"} {
+		require.NoError(t, repo.Put(ctx, "openai", "m", hash, pgvector.NewVector([]float32{1, 2, 3})))
+	}
+	_, err := db.ExecContext(ctx, `UPDATE embedding_cache SET last_used_at = now() - interval '60 days' WHERE text_hash = 'old'`)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `UPDATE embedding_cache SET last_used_at = now() - interval '1 hour' WHERE text_hash = 'a'`)
+	require.NoError(t, err)
+
+	// A hit marks the entry as used
+	_, found, err := repo.Get(ctx, "openai", "m", "a")
+	require.NoError(t, err)
+	require.True(t, found)
+
+	deleted, err := repo.Prune(ctx, 30*24*time.Hour, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	// Keep the two most recently used
+	_, err = db.ExecContext(ctx, `UPDATE embedding_cache SET last_used_at = now() - interval '2 hours' WHERE text_hash = 'b'`)
+	require.NoError(t, err)
+	deleted, err = repo.Prune(ctx, 30*24*time.Hour, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	for hash, want := range map[string]bool{"old": false, "a": true, "b": false, "c": true} {
+		_, found, err := repo.Get(ctx, "openai", "m", hash)
+		require.NoError(t, err)
+		assert.Equal(t, want, found, hash)
+	}
+}
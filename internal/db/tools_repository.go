@@ -2,31 +2,223 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/ddazal/marcopolo-go/internal/models"
 	"github.com/jmoiron/sqlx"
 	"github.com/pgvector/pgvector-go"
 )
 
+// ErrToolNotFound is returned when a tool targeted by name does not exist in the expected state.
+var ErrToolNotFound = errors.New("tool not found")
+
+const (
+	// RRFConstant is the k in reciprocal rank fusion: a tool at rank r in a result list
+	// contributes 1/(k+r). Larger values flatten the difference between top ranks.
+	RRFConstant = 60
+
+	// candidateFactor controls how many candidates each embedding table and ranking
+	// contributes to a search, relative to the requested limit.
+	candidateFactor = 4
+	minCandidates   = 20
+
+	// EmbeddingIndexName is the HNSW index on tools.embedding.
+	EmbeddingIndexName = "tools_embedding_hnsw_idx"
+	// ExampleEmbeddingIndexName is the HNSW index on tool_embeddings.embedding.
+	ExampleEmbeddingIndexName = "tool_embeddings_embedding_hnsw_idx"
+)
+
+// ToolFilter restricts searches by tool metadata. The zero value matches every tool
+// of the default namespace.
+type ToolFilter struct {
+	Namespace   string   // Tools must be in this namespace ("" = default namespace)
+	IncludeTags []string // Tools must have at least one of these tags
+	ExcludeTags []string // Tools must have none of these tags
+	Category    string   // Tools must be in this category
+
+	// Restricted limits tools to an allowlist: a tool must have a name matching one
+	// of AllowedTools, where "*" matches any characters, or one of AllowedTags.
+	Restricted   bool
+	AllowedTools []string
+	AllowedTags  []string
+}
+
+// conditions returns SQL conditions for the filter, to be appended to a WHERE clause
+// on the tools table. The filter's arguments are bound as $first through $first+6,
+// in the order returned by args.
+func (f ToolFilter) conditions(first int) string {
+	return fmt.Sprintf(`
+		AND namespace = $%[7]d
+		AND (cardinality($%[1]d::text[]) = 0 OR tags ?| $%[1]d::text[])
+		AND NOT (tags ?| $%[2]d::text[])
+		AND ($%[3]d = '' OR category = $%[3]d)
+		AND (NOT $%[4]d::boolean OR name LIKE ANY($%[5]d::text[]) OR tags ?| $%[6]d::text[])`,
+		first, first+1, first+2, first+3, first+4, first+5, first+6)
+}
+
+// args returns the filter's query arguments. Lists are never nil, since
+// a NULL array would make the conditions filter out every tool.
+func (f ToolFilter) args() []any {
+	allowedTools := make([]string, len(f.AllowedTools))
+	for i, pattern := range f.AllowedTools {
+		allowedTools[i] = likePattern(pattern)
+	}
+	return []any{nonNil(f.IncludeTags), nonNil(f.ExcludeTags), f.Category,
+		f.Restricted, allowedTools, nonNil(f.AllowedTags), f.Namespace}
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// likeEscaper escapes LIKE's special characters, so only "*" acts as a wildcard
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`, `*`, `%`)
+
+// likePattern converts a tool name pattern, where "*" matches any characters,
+// to a LIKE pattern.
+func likePattern(pattern string) string {
+	return likeEscaper.Replace(pattern)
+}
+
+// vectorMatchesSQL returns CTEs that end in vector_best(tool_id, matched_example, distance):
+// the closest cosine distance to $1 per active tool, over its description embedding and
+// its example embeddings. Each table contributes its nearest $candidates rows, so both
+// can use their HNSW index. The filter is bound from $filterFirst.
+func vectorMatchesSQL(candidates, filterFirst int) string {
+	filter := ToolFilter{}.conditions(filterFirst)
+	return fmt.Sprintf(`
+		vector_matches AS (
+			(
+				SELECT id AS tool_id, NULL::text AS matched_example, embedding <=> $1 AS distance
+				FROM tools
+				WHERE deleted_at IS NULL
+				  AND embedding IS NOT NULL%[2]s
+				ORDER BY embedding <=> $1
+				LIMIT $%[1]d
+			)
+			UNION ALL
+			(
+				SELECT e.tool_id, e.text, e.embedding <=> $1
+				FROM tool_embeddings e
+				JOIN tools ON tools.id = e.tool_id
+				WHERE tools.deleted_at IS NULL%[2]s
+				ORDER BY e.embedding <=> $1
+				LIMIT $%[1]d
+			)
+		),
+		vector_best AS (
+			SELECT DISTINCT ON (tool_id) tool_id, matched_example, distance
+			FROM vector_matches
+			ORDER BY tool_id, distance, matched_example NULLS FIRST
+		)`, candidates, filter)
+}
+
+// SearchOptions tunes vector search queries.
+type SearchOptions struct {
+	// EFSearch is the minimum hnsw.ef_search of vector queries. A query that asks
+	// for more candidates raises it to its candidate count, since an HNSW scan
+	// returns at most ef_search rows. Larger values improve recall at the cost of latency.
+	EFSearch int
+}
+
+// HNSWParams are the build parameters of the HNSW index on tools.embedding.
+type HNSWParams struct {
+	M              int // Maximum connections per node, 2-100
+	EFConstruction int // Candidate list size while building, at least 2*M
+}
+
+// Validate checks the parameters against pgvector's limits.
+func (p HNSWParams) Validate() error {
+	if p.M < 2 || p.M > 100 {
+		return fmt.Errorf("hnsw m must be between 2 and 100, got %d", p.M)
+	}
+	if p.EFConstruction < 2*p.M || p.EFConstruction > 1000 {
+		return fmt.Errorf("hnsw ef_construction must be between 2*m (%d) and 1000, got %d", 2*p.M, p.EFConstruction)
+	}
+	return nil
+}
+
 // ToolWithScore wraps a Tool with its relevance score
 type ToolWithScore struct {
 	*models.Tool
 	RelevanceScore float64 `db:"relevance_score"`
+
+	// MatchedExample is the example query whose embedding matched best,
+	// or nil if the tool's own description matched best or the search was lexical.
+	MatchedExample *string `db:"matched_example"`
 }
 
 // ToolRepository defines the interface for tools table database operations.
 type ToolRepository interface {
-	// UpsertTx inserts or updates a tool within a transaction.
+	// UpsertTx inserts or updates a tool in its namespace within a transaction.
 	UpsertTx(ctx context.Context, tx *sqlx.Tx, tool *models.Tool) error
 
+	// UpdateMetadataTx sets the tags, category, owner and source of the tool's active
+	// row within a transaction, keeping its description and embeddings.
+	// Returns ErrToolNotFound if the namespace has no active tool with that name.
+	UpdateMetadataTx(ctx context.Context, tx *sqlx.Tx, tool *models.Tool) error
+
+	// List returns the active (not soft-deleted) tools of a namespace ordered by name.
+	// Embeddings are not loaded; example texts are.
+	List(ctx context.Context, namespace string) ([]*models.Tool, error)
+
+	// ListDeleted returns the soft-deleted tools of a namespace, most recently deleted first.
+	// Embeddings are not loaded; example texts are.
+	ListDeleted(ctx context.Context, namespace string) ([]*models.Tool, error)
+
+	// SoftDeleteTx marks the active tool with the given name as deleted within a transaction.
+	// Returns ErrToolNotFound if the namespace has no active tool with that name.
+	SoftDeleteTx(ctx context.Context, tx *sqlx.Tx, namespace, name string) error
+
+	// Get returns the active tool with the given name, without its embeddings or examples.
+	// found is false if the namespace has no active tool with that name.
+	Get(ctx context.Context, namespace, name string) (tool *models.Tool, found bool, err error)
+
+	// Restore reactivates the most recently deleted tool with the given name.
+	// Returns ErrToolNotFound if the namespace has no deleted tool with that name,
+	// or if an active tool already uses the name.
+	Restore(ctx context.Context, namespace, name string) error
+
 	// FindSimilarWithScore performs vector similarity search with relevance scores.
-	// Returns tools with relevance score >= minScore, up to limit results.
-	FindSimilarWithScore(ctx context.Context, embedding pgvector.Vector, minScore float64, limit int) ([]*ToolWithScore, error)
+	// Returns tools matching filter with relevance score >= minScore, up to limit results.
+	FindSimilarWithScore(ctx context.Context, embedding pgvector.Vector, minScore float64, limit int, filter ToolFilter) ([]*ToolWithScore, error)
+
+	// FindLexicalWithScore performs full-text search on tool names, descriptions and
+	// parameter descriptions. Tools matching any query word are returned, best first,
+	// with a relevance score in [0, 1), up to limit results.
+	FindLexicalWithScore(ctx context.Context, query string, limit int, filter ToolFilter) ([]*ToolWithScore, error)
+
+	// FindHybridWithScore fuses full-text and vector rankings with reciprocal rank fusion.
+	// Only vector candidates with cosine similarity >= minScore take part; lexical matches
+	// are always considered. Scores are normalized so that a tool ranked first by both is 1.
+	FindHybridWithScore(ctx context.Context, query string, embedding pgvector.Vector, minScore float64, limit int, filter ToolFilter) ([]*ToolWithScore, error)
+
+	// EmbeddingDimensions returns the declared width of the tools.embedding column.
+	// Returns 0 if the column is declared without a fixed dimension, and an error
+	// if tool_embeddings.embedding is declared with a different width.
+	EmbeddingDimensions(ctx context.Context) (int, error)
+
+	// ReplaceEmbeddingsTx replaces the tool's additional embeddings of the given kind
+	// within a transaction.
+	ReplaceEmbeddingsTx(ctx context.Context, tx *sqlx.Tx, toolID int64, kind string, embeddings []*models.ToolEmbedding) error
+
+	// RebuildEmbeddingIndex rebuilds the HNSW indexes on tools.embedding and
+	// tool_embeddings.embedding with the given parameters. Searches keep using
+	// the old indexes until the new ones are ready.
+	RebuildEmbeddingIndex(ctx context.Context, params HNSWParams) error
 }
 
 // PostgresToolRepository implements ToolRepository using PostgreSQL.
 type PostgresToolRepository struct {
-	db *sqlx.DB
+	db   *sqlx.DB
+	opts SearchOptions
 }
 
 // NewPostgresToolRepository creates a new PostgreSQL-backed tool repository.
@@ -34,15 +226,67 @@ func NewPostgresToolRepository(db *sqlx.DB) *PostgresToolRepository {
 	return &PostgresToolRepository{db: db}
 }
 
-// UpsertTx inserts or updates a tool within a transaction.
+// WithSearchOptions sets the options applied to vector queries and returns r.
+func (r *PostgresToolRepository) WithSearchOptions(opts SearchOptions) *PostgresToolRepository {
+	r.opts = opts
+	return r
+}
+
+// searchSettingsSQL sets hnsw.ef_search to $1 for the transaction and, on pgvector
+// 0.8 and later, enables iterative index scans. Every vector query filters at least
+// by namespace after the scan; iterative scans keep reading the index until enough
+// rows pass the filter instead of returning fewer candidates.
+const searchSettingsSQL = `
+	SELECT
+		set_config('hnsw.ef_search', $1, true),
+		CASE WHEN (
+			SELECT string_to_array(extversion, '.')::int[] >= '{0,8}'
+			FROM pg_extension
+			WHERE extname = 'vector'
+		) THEN set_config('hnsw.iterative_scan', 'strict_order', true) END
+`
+
+// selectVector runs a vector search query that takes candidates rows from each
+// HNSW index. hnsw.ef_search and hnsw.iterative_scan are session settings, so the
+// query runs in a read-only transaction that sets them locally.
+func (r *PostgresToolRepository) selectVector(ctx context.Context, candidates int, dest any, query string, args ...any) error {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	efSearch := max(r.opts.EFSearch, candidates)
+	if _, err := tx.ExecContext(ctx, searchSettingsSQL, strconv.Itoa(efSearch)); err != nil {
+		return fmt.Errorf("set hnsw search settings: %w", err)
+	}
+	if err := tx.SelectContext(ctx, dest, query, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpsertTx inserts or updates a tool in its namespace within a transaction.
 func (r *PostgresToolRepository) UpsertTx(ctx context.Context, tx *sqlx.Tx, tool *models.Tool) error {
 	query := `
-		INSERT INTO tools (name, description, embedding, input_schema)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (name) WHERE deleted_at IS NULL DO UPDATE SET
+		INSERT INTO tools (
+			name, description, embedding, input_schema, content_hash, embedding_provider, embedding_model,
+			embedding_template, tags, category, owner, source, namespace
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (namespace, name) WHERE deleted_at IS NULL DO UPDATE SET
 			description = EXCLUDED.description,
 			embedding = EXCLUDED.embedding,
 			input_schema = EXCLUDED.input_schema,
+			content_hash = EXCLUDED.content_hash,
+			embedding_provider = EXCLUDED.embedding_provider,
+			embedding_model = EXCLUDED.embedding_model,
+			embedding_template = EXCLUDED.embedding_template,
+			tags = EXCLUDED.tags,
+			category = EXCLUDED.category,
+			owner = EXCLUDED.owner,
+			source = EXCLUDED.source,
 			updated_at = now()
 		RETURNING id, created_at, updated_at
 	`
@@ -54,28 +298,389 @@ func (r *PostgresToolRepository) UpsertTx(ctx context.Context, tx *sqlx.Tx, tool
 		tool.Description,
 		tool.Embedding,
 		tool.InputSchema,
+		tool.ContentHash,
+		tool.EmbeddingProvider,
+		tool.EmbeddingModel,
+		tool.EmbeddingTemplate,
+		tool.Tags,
+		tool.Category,
+		tool.Owner,
+		tool.Source,
+		tool.Namespace,
+	).Scan(&tool.ID, &tool.CreatedAt, &tool.UpdatedAt)
+}
+
+// UpdateMetadataTx sets the metadata of an active tool within a transaction, keeping its embeddings.
+func (r *PostgresToolRepository) UpdateMetadataTx(ctx context.Context, tx *sqlx.Tx, tool *models.Tool) error {
+	query := `
+		UPDATE tools
+		SET tags = $3, category = $4, owner = $5, source = $6, updated_at = now()
+		WHERE namespace = $1 AND name = $2 AND deleted_at IS NULL
+		RETURNING id, created_at, updated_at
+	`
+
+	err := tx.QueryRowContext(ctx, query,
+		tool.Namespace,
+		tool.Name,
+		tool.Tags,
+		tool.Category,
+		tool.Owner,
+		tool.Source,
 	).Scan(&tool.ID, &tool.CreatedAt, &tool.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrToolNotFound, tool.Name)
+	}
+	return err
+}
+
+// examplesColumn selects the texts of a tool's example embeddings as a jsonb array.
+const examplesColumn = `coalesce((
+				SELECT jsonb_agg(e.text ORDER BY e.id)
+				FROM tool_embeddings e
+				WHERE e.tool_id = tools.id AND e.kind = '` + models.EmbeddingKindExample + `'
+			), '[]') AS examples`
+
+// List returns the active tools of a namespace ordered by name, without their embeddings.
+func (r *PostgresToolRepository) List(ctx context.Context, namespace string) ([]*models.Tool, error) {
+	query := `
+		SELECT
+			id, created_at, updated_at, deleted_at, name, description, input_schema,
+			content_hash, embedding_provider, embedding_model, embedding_template, tags, category, owner,
+			source, namespace, ` + examplesColumn + `
+		FROM tools
+		WHERE deleted_at IS NULL AND namespace = $1
+		ORDER BY name
+	`
+
+	var results []*models.Tool
+	if err := r.db.SelectContext(ctx, &results, query, namespace); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// ListDeleted returns the soft-deleted tools of a namespace, most recently deleted first,
+// without their embeddings.
+func (r *PostgresToolRepository) ListDeleted(ctx context.Context, namespace string) ([]*models.Tool, error) {
+	query := `
+		SELECT
+			id, created_at, updated_at, deleted_at, name, description, input_schema,
+			content_hash, embedding_provider, embedding_model, embedding_template, tags, category, owner,
+			source, namespace, ` + examplesColumn + `
+		FROM tools
+		WHERE deleted_at IS NOT NULL AND namespace = $1
+		ORDER BY deleted_at DESC, id DESC
+	`
+
+	var results []*models.Tool
+	if err := r.db.SelectContext(ctx, &results, query, namespace); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// SoftDeleteTx marks the active tool with the given name as deleted within a transaction.
+func (r *PostgresToolRepository) SoftDeleteTx(ctx context.Context, tx *sqlx.Tx, namespace, name string) error {
+	query := `
+		UPDATE tools
+		SET deleted_at = now(), updated_at = now()
+		WHERE namespace = $1 AND name = $2 AND deleted_at IS NULL
+	`
+
+	result, err := tx.ExecContext(ctx, query, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result, name)
+}
+
+// Get returns the active tool with the given name, without its embeddings or examples.
+func (r *PostgresToolRepository) Get(ctx context.Context, namespace, name string) (*models.Tool, bool, error) {
+	query := `
+		SELECT
+			id, created_at, updated_at, deleted_at, name, description, input_schema,
+			content_hash, embedding_provider, embedding_model, embedding_template, tags, category, owner,
+			source, namespace
+		FROM tools
+		WHERE namespace = $1 AND name = $2 AND deleted_at IS NULL
+	`
+
+	var tool models.Tool
+	err := r.db.GetContext(ctx, &tool, query, namespace, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &tool, true, nil
+}
+
+// Restore reactivates the most recently deleted tool with the given name.
+func (r *PostgresToolRepository) Restore(ctx context.Context, namespace, name string) error {
+	query := `
+		UPDATE tools
+		SET deleted_at = NULL, updated_at = now()
+		WHERE id = (
+			SELECT id FROM tools
+			WHERE namespace = $1 AND name = $2 AND deleted_at IS NOT NULL
+			ORDER BY deleted_at DESC, id DESC
+			LIMIT 1
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM tools WHERE namespace = $1 AND name = $2 AND deleted_at IS NULL
+		)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result, name)
+}
+
+// expectAffected returns ErrToolNotFound when a statement targeting one tool matched no rows.
+func expectAffected(result sql.Result, name string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", ErrToolNotFound, name)
+	}
+	return nil
+}
+
+// ReplaceEmbeddingsTx deletes the tool's embeddings of the given kind and inserts the new ones.
+// The ToolID and Kind of the embeddings are set from the arguments.
+func (r *PostgresToolRepository) ReplaceEmbeddingsTx(ctx context.Context, tx *sqlx.Tx, toolID int64, kind string, embeddings []*models.ToolEmbedding) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tool_embeddings WHERE tool_id = $1 AND kind = $2", toolID, kind); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO tool_embeddings (tool_id, kind, text, embedding)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	for _, embedding := range embeddings {
+		embedding.ToolID = toolID
+		embedding.Kind = kind
+		err := tx.QueryRowContext(ctx, query, toolID, kind, embedding.Text, embedding.Embedding).
+			Scan(&embedding.ID, &embedding.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // FindSimilarWithScore performs vector similarity search using cosine distance with scores.
-func (r *PostgresToolRepository) FindSimilarWithScore(ctx context.Context, embedding pgvector.Vector, minScore float64, limit int) ([]*ToolWithScore, error) {
+// A tool scores by its closest embedding, either its description or one of its examples.
+func (r *PostgresToolRepository) FindSimilarWithScore(ctx context.Context, embedding pgvector.Vector, minScore float64, limit int, filter ToolFilter) ([]*ToolWithScore, error) {
 	query := `
+		WITH` + vectorMatchesSQL(4, 5) + `
+		SELECT
+			t.id, t.created_at, t.updated_at, t.deleted_at, t.name, t.description, t.embedding, t.input_schema,
+			t.content_hash, t.embedding_provider, t.embedding_model, t.tags, t.category, t.owner,
+			b.matched_example,
+			(1 - b.distance)::float8 AS relevance_score
+		FROM vector_best b
+		JOIN tools t ON t.id = b.tool_id
+		WHERE (1 - b.distance) >= $2
+		ORDER BY b.distance, t.name
+		LIMIT $3
+	`
+
+	candidates := max(limit*candidateFactor, minCandidates)
+	args := append([]any{embedding, minScore, limit, candidates}, filter.args()...)
+
+	var results []*ToolWithScore
+	err := r.selectVector(ctx, candidates, &results, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// FindLexicalWithScore ranks tools by ts_rank_cd over the search_vector column.
+// The query is parsed like plainto_tsquery, but its words are OR-ed so that natural
+// language queries still match tools that contain only some of them.
+func (r *PostgresToolRepository) FindLexicalWithScore(ctx context.Context, query string, limit int, filter ToolFilter) ([]*ToolWithScore, error) {
+	sqlQuery := `
 		SELECT
 			id, created_at, updated_at, deleted_at, name, description, embedding, input_schema,
-			(1 - (embedding <=> $1))::float8 AS relevance_score
-		FROM tools
+			content_hash, embedding_provider, embedding_model, tags, category, owner,
+			ts_rank_cd(search_vector, q, 32)::float8 AS relevance_score
+		FROM tools, to_tsquery('simple', replace(plainto_tsquery('english', $1)::text, ' & ', ' | ')) AS q
 		WHERE deleted_at IS NULL
-		  AND embedding IS NOT NULL
-		  AND (1 - (embedding <=> $1)) >= $2
-		ORDER BY embedding <=> $1
-		LIMIT $3
+		  AND search_vector @@ q` + filter.conditions(3) + `
+		ORDER BY relevance_score DESC, name
+		LIMIT $2
 	`
 
+	args := append([]any{query, limit}, filter.args()...)
+
+	var results []*ToolWithScore
+	if err := r.db.SelectContext(ctx, &results, sqlQuery, args...); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// FindHybridWithScore ranks the top vector and full-text candidates separately and
+// sums 1/(RRFConstant+rank) over both lists. The sum is scaled by (RRFConstant+1)/2,
+// its maximum, so relevance scores fall in (0, 1]. Vector ranks use each tool's
+// closest embedding, as in FindSimilarWithScore.
+func (r *PostgresToolRepository) FindHybridWithScore(ctx context.Context, query string, embedding pgvector.Vector, minScore float64, limit int, filter ToolFilter) ([]*ToolWithScore, error) {
+	sqlQuery := `
+		WITH` + vectorMatchesSQL(4, 7) + `,
+		vector_ranked AS (
+			SELECT tool_id AS id, matched_example, row_number() OVER (ORDER BY distance) AS rank
+			FROM vector_best
+			WHERE (1 - distance) >= $3
+			ORDER BY rank
+			LIMIT $4
+		),
+		lexical_ranked AS (
+			SELECT id, row_number() OVER (ORDER BY ts_rank_cd(search_vector, q, 32) DESC) AS rank
+			FROM tools, to_tsquery('simple', replace(plainto_tsquery('english', $2)::text, ' & ', ' | ')) AS q
+			WHERE deleted_at IS NULL
+			  AND search_vector @@ q` + filter.conditions(7) + `
+			ORDER BY rank
+			LIMIT $4
+		),
+		fused AS (
+			SELECT
+				coalesce(v.id, l.id) AS id,
+				v.matched_example,
+				coalesce(1.0 / ($5 + v.rank), 0) + coalesce(1.0 / ($5 + l.rank), 0) AS score
+			FROM vector_ranked v
+			FULL OUTER JOIN lexical_ranked l ON l.id = v.id
+		)
+		SELECT
+			t.id, t.created_at, t.updated_at, t.deleted_at, t.name, t.description, t.embedding, t.input_schema,
+			t.content_hash, t.embedding_provider, t.embedding_model, t.tags, t.category, t.owner,
+			f.matched_example,
+			(f.score * ($5 + 1) / 2)::float8 AS relevance_score
+		FROM fused f
+		JOIN tools t ON t.id = f.id
+		ORDER BY relevance_score DESC, t.name
+		LIMIT $6
+	`
+
+	candidates := max(limit*candidateFactor, minCandidates)
+	args := append([]any{embedding, query, minScore, candidates, RRFConstant, limit}, filter.args()...)
+
 	var results []*ToolWithScore
-	err := r.db.SelectContext(ctx, &results, query, embedding, minScore, limit)
+	err := r.selectVector(ctx, candidates, &results, sqlQuery, args...)
 	if err != nil {
 		return nil, err
 	}
 
 	return results, nil
 }
+
+// EmbeddingDimensions reads the vector widths of tools.embedding and
+// tool_embeddings.embedding from the catalog. For pgvector's vector type,
+// atttypmod holds the declared dimension (-1 when unconstrained).
+func (r *PostgresToolRepository) EmbeddingDimensions(ctx context.Context) (int, error) {
+	dims, err := r.columnDimensions(ctx, "tools")
+	if err != nil {
+		return 0, err
+	}
+	exampleDims, err := r.columnDimensions(ctx, "tool_embeddings")
+	if err != nil {
+		return 0, err
+	}
+
+	if dims != exampleDims {
+		return 0, fmt.Errorf(
+			"tools.embedding is vector(%d) but tool_embeddings.embedding is vector(%d); migrate both columns to the same width",
+			dims, exampleDims,
+		)
+	}
+	return dims, nil
+}
+
+// columnDimensions reads the declared width of table.embedding, 0 when unconstrained
+func (r *PostgresToolRepository) columnDimensions(ctx context.Context, table string) (int, error) {
+	query := `
+		SELECT atttypmod
+		FROM pg_attribute
+		WHERE attrelid = $1::regclass
+		  AND attname = 'embedding'
+		  AND NOT attisdropped
+	`
+
+	var typmod int
+	if err := r.db.GetContext(ctx, &typmod, query, table); err != nil {
+		return 0, fmt.Errorf("read %s.embedding column type: %w", table, err)
+	}
+
+	if typmod < 0 {
+		return 0, nil
+	}
+	return typmod, nil
+}
+
+// RebuildEmbeddingIndex rebuilds the index on tools.embedding, then the one on
+// tool_embeddings.embedding.
+func (r *PostgresToolRepository) RebuildEmbeddingIndex(ctx context.Context, params HNSWParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.rebuildHNSWIndex(ctx, EmbeddingIndexName, "tools", "WHERE deleted_at IS NULL", params); err != nil {
+		return fmt.Errorf("%s: %w", EmbeddingIndexName, err)
+	}
+	if err := r.rebuildHNSWIndex(ctx, ExampleEmbeddingIndexName, "tool_embeddings", "", params); err != nil {
+		return fmt.Errorf("%s: %w", ExampleEmbeddingIndexName, err)
+	}
+	return nil
+}
+
+// rebuildHNSWIndex builds a replacement index concurrently, then swaps it in
+// within a transaction. A replacement left over from an interrupted run is dropped first.
+func (r *PostgresToolRepository) rebuildHNSWIndex(ctx context.Context, name, table, where string, params HNSWParams) error {
+	rebuildName := name + "_rebuild"
+
+	// CREATE INDEX CONCURRENTLY cannot run inside a transaction
+	if _, err := r.db.ExecContext(ctx, "DROP INDEX CONCURRENTLY IF EXISTS "+rebuildName); err != nil {
+		return fmt.Errorf("drop leftover index: %w", err)
+	}
+
+	// Index parameters cannot be bound, but they are validated integers
+	create := fmt.Sprintf(`
+		CREATE INDEX CONCURRENTLY %s ON %s
+		USING hnsw (embedding vector_cosine_ops) WITH (m = %d, ef_construction = %d)
+		%s
+	`, rebuildName, table, params.M, params.EFConstruction, where)
+	if _, err := r.db.ExecContext(ctx, create); err != nil {
+		return fmt.Errorf("build index: %w", err)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DROP INDEX IF EXISTS "+name); err != nil {
+		return fmt.Errorf("drop index: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER INDEX %s RENAME TO %s", rebuildName, name)); err != nil {
+		return fmt.Errorf("rename index: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -251,7 +252,7 @@ func TestPostgresToolRepository_FindSimilarWithScore(t *testing.T) {
 			}
 
 			// Execute query
-			results, err := repo.FindSimilarWithScore(ctx, tc.queryEmbedding, tc.minScore, tc.limit)
+			results, err := repo.FindSimilarWithScore(ctx, tc.queryEmbedding, tc.minScore, tc.limit, ToolFilter{})
 			require.NoError(t, err)
 
 			assert.Len(t, results, tc.expectedCount)
@@ -289,3 +290,573 @@ func TestPostgresToolRepository_FindSimilarWithScore(t *testing.T) {
 		})
 	}
 }
+
+func TestPostgresToolRepository_EmbeddingDimensions(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewPostgresToolRepository(db)
+
+	dims, err := repo.EmbeddingDimensions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1536, dims)
+
+	// Example embeddings must have the same width as tool embeddings
+	_, err = db.Exec("ALTER TABLE tool_embeddings ALTER COLUMN embedding TYPE vector(768)")
+	require.NoError(t, err)
+	_, err = repo.EmbeddingDimensions(context.Background())
+	assert.ErrorContains(t, err, "tool_embeddings.embedding is vector(768)")
+}
+
+func TestPostgresToolRepository_List(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewPostgresToolRepository(db)
+	ctx := context.Background()
+
+	embedding := pgvector.NewVector(make([]float32, 1536))
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	for _, tool := range []*models.Tool{
+		{Name: "tool_b", Description: "B", Embedding: embedding, ContentHash: "hash-b", EmbeddingProvider: "openai", EmbeddingModel: "text-embedding-3-small", EmbeddingTemplate: "0123456789ab"},
+		{Name: "tool_a", Description: "A", Embedding: embedding, ContentHash: "hash-a", EmbeddingProvider: "openai", EmbeddingModel: "text-embedding-3-small"},
+	} {
+		require.NoError(t, repo.UpsertTx(ctx, tx, tool))
+	}
+	require.NoError(t, tx.Commit())
+
+	results, err := repo.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "tool_a", results[0].Name)
+	assert.Equal(t, "hash-a", results[0].ContentHash)
+	assert.Equal(t, "openai", results[0].EmbeddingProvider)
+	assert.Equal(t, "text-embedding-3-small", results[0].EmbeddingModel)
+	assert.Empty(t, results[0].EmbeddingTemplate)
+	assert.Equal(t, "tool_b", results[1].Name)
+	assert.Equal(t, "0123456789ab", results[1].EmbeddingTemplate)
+}
+
+func TestPostgresToolRepository_UpdateMetadataTx(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewPostgresToolRepository(db)
+	ctx := context.Background()
+
+	embedding := make([]float32, 1536)
+	embedding[0] = 1
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	require.NoError(t, repo.UpsertTx(ctx, tx, &models.Tool{
+		Name: "get_holidays", Description: "Holidays", Embedding: pgvector.NewVector(embedding),
+		ContentHash: "hash", Tags: models.StringList{"calendar"},
+	}))
+	require.NoError(t, repo.UpdateMetadataTx(ctx, tx, &models.Tool{
+		Name: "get_holidays", Tags: models.StringList{"calendar", "public"}, Category: "reference", Owner: "team-a",
+	}))
+	assert.ErrorIs(t, repo.UpdateMetadataTx(ctx, tx, &models.Tool{Name: "missing"}), ErrToolNotFound)
+	require.NoError(t, tx.Commit())
+
+	tools, err := repo.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, tools, 1)
+	assert.Equal(t, models.StringList{"calendar", "public"}, tools[0].Tags)
+	assert.Equal(t, "reference", tools[0].Category)
+	assert.Equal(t, "team-a", tools[0].Owner)
+	assert.Equal(t, "Holidays", tools[0].Description)
+	assert.Equal(t, "hash", tools[0].ContentHash)
+
+	// The stored embedding is kept
+	results, err := repo.FindSimilarWithScore(ctx, pgvector.NewVector(embedding), 0.99, 5, ToolFilter{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "get_holidays", results[0].Name)
+}
+
+func TestPostgresToolRepository_SoftDeleteAndRestore(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewPostgresToolRepository(db)
+	ctx := context.Background()
+
+	embedding := pgvector.NewVector(make([]float32, 1536))
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	require.NoError(t, repo.UpsertTx(ctx, tx, &models.Tool{Name: "retired_tool", Description: "Retired", Embedding: embedding}))
+	require.NoError(t, repo.UpsertTx(ctx, tx, &models.Tool{Name: "kept_tool", Description: "Kept", Embedding: embedding}))
+	require.NoError(t, tx.Commit())
+
+	// Soft delete hides the tool from List
+	tx, err = db.Beginx()
+	require.NoError(t, err)
+	require.NoError(t, repo.SoftDeleteTx(ctx, tx, "", "retired_tool"))
+	require.NoError(t, tx.Commit())
+
+	active, err := repo.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, "kept_tool", active[0].Name)
+
+	deleted, err := repo.ListDeleted(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	assert.Equal(t, "retired_tool", deleted[0].Name)
+	assert.NotNil(t, deleted[0].DeletedAt)
+
+	// Deleting again reports not found
+	tx, err = db.Beginx()
+	require.NoError(t, err)
+	assert.ErrorIs(t, repo.SoftDeleteTx(ctx, tx, "", "retired_tool"), ErrToolNotFound)
+	require.NoError(t, tx.Rollback())
+
+	// Restore brings it back
+	require.NoError(t, repo.Restore(ctx, "", "retired_tool"))
+	active, err = repo.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, active, 2)
+
+	// Restoring an active tool reports not found
+	assert.ErrorIs(t, repo.Restore(ctx, "", "retired_tool"), ErrToolNotFound)
+}
+
+func TestPostgresToolRepository_FindLexicalWithScore(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewPostgresToolRepository(db)
+	ctx := context.Background()
+
+	schema := `{"properties": {"countryCode": {"type": "string", "description": "A valid ISO 3166-1 alpha-2 country code."}}}`
+	embedding := pgvector.NewVector(make([]float32, 1536))
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	for _, tool := range []*models.Tool{
+		{Name: "get_holidays", Description: "Tool: get_holidays\nDescription: Retrieve public holidays", Embedding: embedding, InputSchema: &schema},
+		{Name: "get_weather", Description: "Tool: get_weather\nDescription: Current weather for a city", Embedding: embedding},
+	} {
+		require.NoError(t, repo.UpsertTx(ctx, tx, tool))
+	}
+	require.NoError(t, tx.Commit())
+
+	tests := map[string]struct {
+		query         string
+		expectedNames []string
+	}{
+		"matches tool name": {
+			query:         "get_weather",
+			expectedNames: []string{"get_weather"},
+		},
+		"matches parameter descriptions": {
+			query:         "ISO 3166",
+			expectedNames: []string{"get_holidays"},
+		},
+		"ignores schema keywords": {
+			query:         "string properties",
+			expectedNames: []string{},
+		},
+		"matches any query word": {
+			query:         "which holidays are there in Germany",
+			expectedNames: []string{"get_holidays"},
+		},
+		"returns empty slice when nothing matches": {
+			query:         "currency exchange",
+			expectedNames: []string{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			results, err := repo.FindLexicalWithScore(ctx, tc.query, 10, ToolFilter{})
+			require.NoError(t, err)
+
+			resultNames := make([]string, len(results))
+			for i, r := range results {
+				resultNames[i] = r.Name
+				assert.Greater(t, r.RelevanceScore, 0.0)
+				assert.Less(t, r.RelevanceScore, 1.0)
+			}
+			assert.Equal(t, tc.expectedNames, resultNames)
+		})
+	}
+}
+
+func TestPostgresToolRepository_FindHybridWithScore(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewPostgresToolRepository(db)
+	ctx := context.Background()
+
+	makeEmbedding := func(seed int) pgvector.Vector {
+		embedding := make([]float32, 1536)
+		for i := range embedding {
+			embedding[i] = float32(seed)*0.1 + float32(i%100)*0.01
+		}
+		return pgvector.NewVector(embedding)
+	}
+
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	for _, tool := range []*models.Tool{
+		{Name: "semantic_match", Description: "Looks up calendars", Embedding: makeEmbedding(6)},
+		{Name: "keyword_match", Description: "Lists nager holidays", Embedding: makeEmbedding(-20)},
+		{Name: "both_match", Description: "Lists nager calendars from the nager API", Embedding: makeEmbedding(5)},
+	} {
+		require.NoError(t, repo.UpsertTx(ctx, tx, tool))
+	}
+	require.NoError(t, tx.Commit())
+
+	results, err := repo.FindHybridWithScore(ctx, "nager", makeEmbedding(5), 0.99, 10, ToolFilter{})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	// Ranked by both searches, so it comes first with the maximum score
+	assert.Equal(t, "both_match", results[0].Name)
+	assert.InDelta(t, 1.0, results[0].RelevanceScore, 1e-9)
+
+	// The lexical-only match is fused in even though it is below the vector threshold
+	names := []string{results[1].Name, results[2].Name}
+	assert.ElementsMatch(t, []string{"semantic_match", "keyword_match"}, names)
+	for _, r := range results[1:] {
+		assert.Less(t, r.RelevanceScore, results[0].RelevanceScore)
+	}
+}
+
+func TestHNSWParams_Validate(t *testing.T) {
+	tests := map[string]struct {
+		params  HNSWParams
+		wantErr bool
+	}{
+		"pgvector defaults": {params: HNSWParams{M: 16, EFConstruction: 64}},
+		"m too small":       {params: HNSWParams{M: 1, EFConstruction: 64}, wantErr: true},
+		"m too large":       {params: HNSWParams{M: 101, EFConstruction: 400}, wantErr: true},
+		"ef_construction below twice m": {
+			params:  HNSWParams{M: 16, EFConstruction: 31},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tc.params.Validate()
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPostgresToolRepository_RebuildEmbeddingIndex(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewPostgresToolRepository(db).WithSearchOptions(SearchOptions{EFSearch: 100})
+	ctx := context.Background()
+
+	embedding := make([]float32, 1536)
+	for i := range embedding {
+		embedding[i] = float32(i%100) * 0.01
+	}
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	require.NoError(t, repo.UpsertTx(ctx, tx, &models.Tool{Name: "indexed_tool", Description: "Indexed", Embedding: pgvector.NewVector(embedding)}))
+	require.NoError(t, tx.Commit())
+
+	require.NoError(t, repo.RebuildEmbeddingIndex(ctx, HNSWParams{M: 8, EFConstruction: 32}))
+
+	var definition string
+	require.NoError(t, db.GetContext(ctx, &definition, "SELECT indexdef FROM pg_indexes WHERE indexname = $1", EmbeddingIndexName))
+	assert.Contains(t, definition, "m='8'")
+	assert.Contains(t, definition, "ef_construction='32'")
+	require.NoError(t, db.GetContext(ctx, &definition, "SELECT indexdef FROM pg_indexes WHERE indexname = $1", ExampleEmbeddingIndexName))
+	assert.Contains(t, definition, "m='8'")
+
+	// Vector search applies ef_search and still finds the tool
+	results, err := repo.FindSimilarWithScore(ctx, pgvector.NewVector(embedding), 0.9, 5, ToolFilter{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "indexed_tool", results[0].Name)
+}
+
+func TestPostgresToolRepository_CandidatesAboveEFSearch(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// One connection, so the session can be forced onto the HNSW indexes
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	_, err := db.ExecContext(ctx, "SET enable_seqscan = off")
+	require.NoError(t, err)
+
+	repo := NewPostgresToolRepository(db).WithSearchOptions(SearchOptions{EFSearch: 2})
+
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	for i := range 30 {
+		embedding := make([]float32, 1536)
+		embedding[0] = 1
+		embedding[i+1] = 0.1
+		require.NoError(t, repo.UpsertTx(ctx, tx, &models.Tool{
+			Name: fmt.Sprintf("tool_%02d", i), Description: "Tool", Embedding: pgvector.NewVector(embedding),
+			Tags: models.StringList{fmt.Sprintf("group_%d", i%3)},
+		}))
+	}
+	require.NoError(t, tx.Commit())
+
+	query := make([]float32, 1536)
+	query[0] = 1
+
+	// 10 results need 40 candidates, far above ef_search
+	results, err := repo.FindSimilarWithScore(ctx, pgvector.NewVector(query), 0, 10, ToolFilter{})
+	require.NoError(t, err)
+	assert.Len(t, results, 10)
+
+	// A filter that rejects most scanned rows still fills the limit
+	results, err = repo.FindSimilarWithScore(ctx, pgvector.NewVector(query), 0, 10, ToolFilter{IncludeTags: []string{"group_0"}})
+	require.NoError(t, err)
+	assert.Len(t, results, 10)
+
+	results, err = repo.FindHybridWithScore(ctx, "unmatched", pgvector.NewVector(query), 0, 10, ToolFilter{})
+	require.NoError(t, err)
+	assert.Len(t, results, 10)
+}
+
+func TestPostgresToolRepository_FindSimilarWithScoreFilters(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewPostgresToolRepository(db)
+	ctx := context.Background()
+
+	embedding := make([]float32, 1536)
+	for i := range embedding {
+		embedding[i] = float32(i%100) * 0.01
+	}
+	vec := pgvector.NewVector(embedding)
+
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	for _, tool := range []*models.Tool{
+		{Name: "get_holidays", Description: "Holidays", Embedding: vec, Tags: models.StringList{"calendar", "holidays"}, Category: "calendar"},
+		{Name: "get_holidays_v1", Description: "Holidays", Embedding: vec, Tags: models.StringList{"calendar", "deprecated"}, Category: "calendar"},
+		{Name: "convert_currency", Description: "Currency", Embedding: vec, Tags: models.StringList{"finance"}, Category: "finance", Owner: "payments"},
+		{Name: "untagged", Description: "Untagged", Embedding: vec},
+	} {
+		require.NoError(t, repo.UpsertTx(ctx, tx, tool))
+	}
+	require.NoError(t, tx.Commit())
+
+	tests := map[string]struct {
+		filter        ToolFilter
+		expectedNames []string
+	}{
+		"empty filter matches everything": {
+			filter:        ToolFilter{},
+			expectedNames: []string{"convert_currency", "get_holidays", "get_holidays_v1", "untagged"},
+		},
+		"include tags matches any of them": {
+			filter:        ToolFilter{IncludeTags: []string{"holidays", "finance"}},
+			expectedNames: []string{"convert_currency", "get_holidays"},
+		},
+		"exclude tags removes tagged tools": {
+			filter:        ToolFilter{ExcludeTags: []string{"deprecated"}},
+			expectedNames: []string{"convert_currency", "get_holidays", "untagged"},
+		},
+		"category and exclude tags combine": {
+			filter:        ToolFilter{Category: "calendar", ExcludeTags: []string{"deprecated"}},
+			expectedNames: []string{"get_holidays"},
+		},
+		"allowlist matches name patterns or tags": {
+			filter:        ToolFilter{Restricted: true, AllowedTools: []string{"get_holidays_*", "untagged"}, AllowedTags: []string{"finance"}},
+			expectedNames: []string{"convert_currency", "get_holidays_v1", "untagged"},
+		},
+		"allowlist treats underscores literally": {
+			filter:        ToolFilter{Restricted: true, AllowedTools: []string{"get_holidays_"}},
+			expectedNames: []string{},
+		},
+		"empty allowlist matches nothing": {
+			filter:        ToolFilter{Restricted: true},
+			expectedNames: []string{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			results, err := repo.FindSimilarWithScore(ctx, vec, 0.9, 10, tc.filter)
+			require.NoError(t, err)
+
+			resultNames := make([]string, len(results))
+			for i, r := range results {
+				resultNames[i] = r.Name
+			}
+			assert.ElementsMatch(t, tc.expectedNames, resultNames)
+		})
+	}
+
+	// Metadata is read back with the tool
+	tools, err := repo.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, tools, 4)
+	assert.Equal(t, "convert_currency", tools[0].Name)
+	assert.Equal(t, models.StringList{"finance"}, tools[0].Tags)
+	assert.Equal(t, "finance", tools[0].Category)
+	assert.Equal(t, "payments", tools[0].Owner)
+}
+
+func TestPostgresToolRepository_FindSimilarWithScoreExamples(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewPostgresToolRepository(db)
+	ctx := context.Background()
+
+	makeEmbedding := func(seed int) pgvector.Vector {
+		embedding := make([]float32, 1536)
+		for i := range embedding {
+			embedding[i] = float32(seed)*0.1 + float32(i%100)*0.01
+		}
+		return pgvector.NewVector(embedding)
+	}
+
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	tool := &models.Tool{Name: "get_holidays", Description: "Holidays", Embedding: makeEmbedding(-20)}
+	require.NoError(t, repo.UpsertTx(ctx, tx, tool))
+	require.NoError(t, repo.ReplaceEmbeddingsTx(ctx, tx, tool.ID, models.EmbeddingKindExample, []*models.ToolEmbedding{
+		{Text: "Is Monday a bank holiday?", Embedding: makeEmbedding(5)},
+		{Text: "Public holidays in Germany", Embedding: makeEmbedding(1)},
+	}))
+	require.NoError(t, tx.Commit())
+
+	// The description is far from the query, but an example matches
+	results, err := repo.FindSimilarWithScore(ctx, makeEmbedding(5), 0.99, 5, ToolFilter{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "get_holidays", results[0].Name)
+	require.NotNil(t, results[0].MatchedExample)
+	assert.Equal(t, "Is Monday a bank holiday?", *results[0].MatchedExample)
+	assert.InDelta(t, 1.0, results[0].RelevanceScore, 1e-6)
+
+	// A query closest to the description reports no example
+	results, err = repo.FindSimilarWithScore(ctx, makeEmbedding(-20), 0.99, 5, ToolFilter{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Nil(t, results[0].MatchedExample)
+
+	// List loads example texts in insertion order
+	tools, err := repo.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, tools, 1)
+	assert.Equal(t, models.StringList{"Is Monday a bank holiday?", "Public holidays in Germany"}, tools[0].Examples)
+
+	// Replacing with no examples removes them
+	tx, err = db.Beginx()
+	require.NoError(t, err)
+	require.NoError(t, repo.ReplaceEmbeddingsTx(ctx, tx, tool.ID, models.EmbeddingKindExample, nil))
+	require.NoError(t, tx.Commit())
+
+	tools, err = repo.List(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, tools[0].Examples)
+}
+
+func TestPostgresToolRepository_Namespaces(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewPostgresToolRepository(db)
+	ctx := context.Background()
+
+	embedding := make([]float32, 1536)
+	for i := range embedding {
+		embedding[i] = float32(i%100) * 0.01
+	}
+	vec := pgvector.NewVector(embedding)
+
+	// The same name can be used once per namespace
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	require.NoError(t, repo.UpsertTx(ctx, tx, &models.Tool{Name: "get_holidays", Description: "Default", Embedding: vec, Tags: models.StringList{"calendar"}}))
+	require.NoError(t, repo.UpsertTx(ctx, tx, &models.Tool{Name: "get_holidays", Description: "Payments", Embedding: vec, Namespace: "payments"}))
+	require.NoError(t, repo.UpsertTx(ctx, tx, &models.Tool{Name: "refund", Description: "Refund", Embedding: vec, Namespace: "payments"}))
+	require.NoError(t, tx.Commit())
+
+	defaultTools, err := repo.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, defaultTools, 1)
+	assert.Equal(t, "Default", defaultTools[0].Description)
+
+	paymentsTools, err := repo.List(ctx, "payments")
+	require.NoError(t, err)
+	require.Len(t, paymentsTools, 2)
+	assert.Equal(t, "payments", paymentsTools[0].Namespace)
+
+	results, err := repo.FindSimilarWithScore(ctx, vec, 0.9, 10, ToolFilter{Namespace: "payments"})
+	require.NoError(t, err)
+	names := make([]string, len(results))
+	for i, r := range results {
+		names[i] = r.Name
+	}
+	assert.ElementsMatch(t, []string{"get_holidays", "refund"}, names)
+
+	results, err = repo.FindLexicalWithScore(ctx, "refund", 10, ToolFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	tool, found, err := repo.Get(ctx, "", "get_holidays")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, models.StringList{"calendar"}, tool.Tags)
+
+	_, found, err = repo.Get(ctx, "", "refund")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// Soft deletes only touch their namespace
+	tx, err = db.Beginx()
+	require.NoError(t, err)
+	require.NoError(t, repo.SoftDeleteTx(ctx, tx, "payments", "get_holidays"))
+	require.NoError(t, tx.Commit())
+
+	defaultTools, err = repo.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, defaultTools, 1)
+}
+
+func TestPostgresToolRepository_Get(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewPostgresToolRepository(db)
+	ctx := context.Background()
+
+	vec := pgvector.NewVector(make([]float32, 1536))
+	schema := `{"properties":{"year":{"type":"string"}},"required":["year"]}`
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	require.NoError(t, repo.UpsertTx(ctx, tx, &models.Tool{Name: "get_holidays", Description: "Holidays", Embedding: vec, InputSchema: &schema}))
+	require.NoError(t, repo.UpsertTx(ctx, tx, &models.Tool{Name: "ping", Description: "Ping", Embedding: vec}))
+	require.NoError(t, tx.Commit())
+
+	got, found, err := repo.Get(ctx, "", "get_holidays")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Holidays", got.Description)
+	require.NotNil(t, got.InputSchema)
+	assert.JSONEq(t, schema, *got.InputSchema)
+
+	got, found, err = repo.Get(ctx, "", "ping")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Nil(t, got.InputSchema)
+
+	_, found, err = repo.Get(ctx, "payments", "get_holidays")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
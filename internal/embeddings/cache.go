@@ -0,0 +1,191 @@
+package embeddings
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheKey identifies a cached embedding. TextHash is the SHA-256 of the
+// normalized text, so the key is safe to persist and index.
+type CacheKey struct {
+	Provider string
+	Model    string
+	TextHash string
+}
+
+// CacheStore is an optional second-level cache shared across processes,
+// such as a database table.
+type CacheStore interface {
+	GetEmbedding(ctx context.Context, key CacheKey) ([]float32, bool, error)
+	PutEmbedding(ctx context.Context, key CacheKey, embedding []float32) error
+}
+
+// CacheStats reports cache effectiveness counters.
+type CacheStats struct {
+	Hits        uint64 `json:"hits"`         // Served from memory
+	StoreHits   uint64 `json:"store_hits"`   // Served from the CacheStore
+	Misses      uint64 `json:"misses"`       // Generated by the wrapped provider
+	StoreErrors uint64 `json:"store_errors"` // CacheStore failures (treated as misses)
+}
+
+// CachedProvider wraps a Provider and caches single-text embeddings, which is
+// the shape of search queries. Lookups go to an in-process LRU with TTL first,
+// then to the optional CacheStore. Batch calls pass through uncached.
+type CachedProvider struct {
+	Provider
+	provider string
+	model    string
+	store    CacheStore
+
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	order    *list.List // front = most recently used
+
+	hits        atomic.Uint64
+	storeHits   atomic.Uint64
+	misses      atomic.Uint64
+	storeErrors atomic.Uint64
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+type cacheEntry struct {
+	hash      string
+	embedding []float32
+	expiresAt time.Time
+}
+
+// NewCachedProvider creates a caching decorator around provider. providerName and
+// model scope the persisted keys so that vectors from different models never mix.
+// A non-positive ttl keeps entries until they are evicted; store may be nil.
+func NewCachedProvider(provider Provider, providerName, model string, capacity int, ttl time.Duration, store CacheStore) *CachedProvider {
+	return &CachedProvider{
+		Provider: provider,
+		provider: providerName,
+		model:    model,
+		store:    store,
+		capacity: max(capacity, 1),
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// GenerateEmbedding returns a cached embedding for text, generating and caching it on a miss.
+func (p *CachedProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	hash := hashText(text)
+
+	if embedding, ok := p.getMemory(hash); ok {
+		p.hits.Add(1)
+		return embedding, nil
+	}
+
+	key := CacheKey{Provider: p.provider, Model: p.model, TextHash: hash}
+
+	if p.store != nil {
+		embedding, ok, err := p.store.GetEmbedding(ctx, key)
+		if err != nil {
+			p.storeErrors.Add(1)
+		} else if ok {
+			p.storeHits.Add(1)
+			p.putMemory(hash, embedding)
+			return embedding, nil
+		}
+	}
+
+	p.misses.Add(1)
+	embedding, err := p.Provider.GenerateEmbedding(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+
+	p.putMemory(hash, embedding)
+	if p.store != nil {
+		if err := p.store.PutEmbedding(ctx, key, embedding); err != nil {
+			p.storeErrors.Add(1)
+		}
+	}
+
+	return embedding, nil
+}
+
+// Stats returns a snapshot of the cache counters.
+func (p *CachedProvider) Stats() CacheStats {
+	return CacheStats{
+		Hits:        p.hits.Load(),
+		StoreHits:   p.storeHits.Load(),
+		Misses:      p.misses.Load(),
+		StoreErrors: p.storeErrors.Load(),
+	}
+}
+
+func (p *CachedProvider) getMemory(hash string) ([]float32, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	elem, ok := p.entries[hash]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if !entry.expiresAt.IsZero() && p.now().After(entry.expiresAt) {
+		p.order.Remove(elem)
+		delete(p.entries, hash)
+		return nil, false
+	}
+
+	p.order.MoveToFront(elem)
+	return entry.embedding, true
+}
+
+func (p *CachedProvider) putMemory(hash string, embedding []float32) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var expiresAt time.Time
+	if p.ttl > 0 {
+		expiresAt = p.now().Add(p.ttl)
+	}
+
+	if elem, ok := p.entries[hash]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.embedding = embedding
+		entry.expiresAt = expiresAt
+		p.order.MoveToFront(elem)
+		return
+	}
+
+	p.entries[hash] = p.order.PushFront(&cacheEntry{
+		hash:      hash,
+		embedding: embedding,
+		expiresAt: expiresAt,
+	})
+
+	for p.order.Len() > p.capacity {
+		oldest := p.order.Back()
+		p.order.Remove(oldest)
+		delete(p.entries, oldest.Value.(*cacheEntry).hash)
+	}
+}
+
+// NormalizeText folds case and whitespace so trivially different queries share a cache entry.
+func NormalizeText(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// hashText returns the hex SHA-256 of the normalized text.
+func hashText(text string) string {
+	sum := sha256.Sum256([]byte(NormalizeText(text)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,145 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingProvider returns a distinct embedding per call so cache hits are observable
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) GenerateEmbedding(_ context.Context, _ string) ([]float32, error) {
+	p.calls++
+	return []float32{float32(p.calls)}, nil
+}
+
+func (p *countingProvider) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	return generateSequentially(ctx, p, texts)
+}
+
+func (p *countingProvider) MaxBatchSize() int { return 16 }
+
+func (p *countingProvider) GetDimensions() int { return 1 }
+
+// memoryStore is an in-memory CacheStore
+type memoryStore struct {
+	entries map[CacheKey][]float32
+	err     error
+}
+
+func (s *memoryStore) GetEmbedding(_ context.Context, key CacheKey) ([]float32, bool, error) {
+	if s.err != nil {
+		return nil, false, s.err
+	}
+	embedding, ok := s.entries[key]
+	return embedding, ok, nil
+}
+
+func (s *memoryStore) PutEmbedding(_ context.Context, key CacheKey, embedding []float32) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.entries[key] = embedding
+	return nil
+}
+
+func TestCachedProvider_GenerateEmbedding(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("serves normalized repeats from memory", func(t *testing.T) {
+		base := &countingProvider{}
+		cached := NewCachedProvider(base, "openai", "text-embedding-3-small", 10, time.Hour, nil)
+
+		first, err := cached.GenerateEmbedding(ctx, "Public holidays in Germany")
+		require.NoError(t, err)
+		second, err := cached.GenerateEmbedding(ctx, "  public   HOLIDAYS in germany ")
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, base.calls)
+		assert.Equal(t, CacheStats{Hits: 1, Misses: 1}, cached.Stats())
+	})
+
+	t.Run("expires entries after ttl", func(t *testing.T) {
+		base := &countingProvider{}
+		cached := NewCachedProvider(base, "openai", "text-embedding-3-small", 10, time.Minute, nil)
+		now := time.Unix(0, 0)
+		cached.now = func() time.Time { return now }
+
+		_, err := cached.GenerateEmbedding(ctx, "query")
+		require.NoError(t, err)
+
+		now = now.Add(2 * time.Minute)
+		_, err = cached.GenerateEmbedding(ctx, "query")
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, base.calls)
+		assert.Equal(t, uint64(2), cached.Stats().Misses)
+	})
+
+	t.Run("evicts least recently used entry", func(t *testing.T) {
+		base := &countingProvider{}
+		cached := NewCachedProvider(base, "openai", "text-embedding-3-small", 2, 0, nil)
+
+		for _, query := range []string{"a", "b", "a", "c", "a", "b"} {
+			_, err := cached.GenerateEmbedding(ctx, query)
+			require.NoError(t, err)
+		}
+
+		// "b" was evicted when "c" arrived; "a" stayed hot
+		assert.Equal(t, 4, base.calls)
+		assert.Equal(t, CacheStats{Hits: 2, Misses: 4}, cached.Stats())
+	})
+
+	t.Run("falls back to store and fills it", func(t *testing.T) {
+		store := &memoryStore{entries: map[CacheKey][]float32{}}
+
+		first := NewCachedProvider(&countingProvider{}, "openai", "text-embedding-3-small", 10, time.Hour, store)
+		_, err := first.GenerateEmbedding(ctx, "query")
+		require.NoError(t, err)
+		require.Len(t, store.entries, 1)
+
+		// A second process shares the store
+		base := &countingProvider{}
+		second := NewCachedProvider(base, "openai", "text-embedding-3-small", 10, time.Hour, store)
+		embedding, err := second.GenerateEmbedding(ctx, "Query")
+		require.NoError(t, err)
+
+		assert.Equal(t, []float32{1}, embedding)
+		assert.Equal(t, 0, base.calls)
+		assert.Equal(t, CacheStats{StoreHits: 1}, second.Stats())
+	})
+
+	t.Run("keys store entries by provider and model", func(t *testing.T) {
+		store := &memoryStore{entries: map[CacheKey][]float32{}}
+
+		_, err := NewCachedProvider(&countingProvider{}, "openai", "text-embedding-3-small", 10, 0, store).GenerateEmbedding(ctx, "query")
+		require.NoError(t, err)
+
+		base := &countingProvider{}
+		_, err = NewCachedProvider(base, "openai", "text-embedding-3-large", 10, 0, store).GenerateEmbedding(ctx, "query")
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, base.calls)
+		assert.Len(t, store.entries, 2)
+	})
+
+	t.Run("treats store errors as misses", func(t *testing.T) {
+		base := &countingProvider{}
+		store := &memoryStore{err: errors.New("connection refused")}
+		cached := NewCachedProvider(base, "openai", "text-embedding-3-small", 10, 0, store)
+
+		embedding, err := cached.GenerateEmbedding(ctx, "query")
+		require.NoError(t, err)
+
+		assert.Equal(t, []float32{1}, embedding)
+		assert.Equal(t, CacheStats{Misses: 1, StoreErrors: 2}, cached.Stats())
+	})
+}
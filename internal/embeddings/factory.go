@@ -4,17 +4,55 @@ import (
 	"fmt"
 
 	"github.com/ddazal/marcopolo-go/internal/config"
+	"github.com/openai/openai-go/v3/option"
 )
 
 // NewProvider creates an embedding provider based on configuration.
 func NewProvider(cfg config.Config) (Provider, error) {
+	if cfg.Embedding.Dimensions < 0 {
+		return nil, fmt.Errorf("dimensions must not be negative")
+	}
+
 	switch cfg.Embedding.Provider {
 	case "openai":
-		if cfg.Embedding.ApiKey == "" {
+		// Self-hosted compatible gateways often run without authentication
+		if cfg.Embedding.ApiKey == "" && cfg.Embedding.BaseURL == "" {
 			return nil, fmt.Errorf("openai_api_key is required for OpenAI provider")
 		}
-		return NewOpenAIProvider(cfg.Embedding.ApiKey, cfg.Embedding.Model), nil
+		opts, err := openAIClientOptions(cfg.Embedding)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Embedding.Retry.MaxAttempts > 1 {
+			// RetryProvider owns retries; don't multiply them with the SDK's own
+			opts = append(opts, option.WithMaxRetries(0))
+		}
+		provider := NewOpenAIProvider(cfg.Embedding.ApiKey, cfg.Embedding.Model, cfg.Embedding.Dimensions, opts...)
+		return withRemoteLimits(provider, cfg.Embedding), nil
+	case "ollama":
+		if cfg.Embedding.Model == "" {
+			return nil, fmt.Errorf("model is required for Ollama provider")
+		}
+		if cfg.Embedding.Dimensions > 0 {
+			return nil, fmt.Errorf("dimensions is not supported by the Ollama provider; the model's native size is used")
+		}
+		provider := NewOllamaProvider(cfg.Embedding.BaseURL, cfg.Embedding.Model)
+		return withRemoteLimits(provider, cfg.Embedding), nil
+	case "local-hash":
+		return NewLocalHashProvider(cfg.Embedding.Dimensions), nil
 	default:
 		return nil, fmt.Errorf("unsupported embedding provider: %s", cfg.Embedding.Provider)
 	}
 }
+
+// withRemoteLimits wraps a network-backed provider with rate limiting and retries.
+// Retries sit outside the limiter so every attempt is throttled.
+func withRemoteLimits(provider Provider, cfg config.EmbeddingConfig) Provider {
+	if cfg.RateLimit.RequestsPerMinute > 0 || cfg.RateLimit.TokensPerMinute > 0 {
+		provider = NewRateLimitedProvider(provider, cfg.RateLimit)
+	}
+	if cfg.Retry.MaxAttempts > 1 {
+		provider = NewRetryProvider(provider, cfg.Retry)
+	}
+	return provider
+}
@@ -37,6 +37,59 @@ func TestNewProvider(t *testing.T) {
 			expectError: true,
 			errorMsg:    "openai_api_key is required",
 		},
+		"creates openai provider for keyless compatible gateway": {
+			config: config.Config{
+				Embedding: config.EmbeddingConfig{
+					Provider: "openai",
+					Model:    "BAAI/bge-small-en-v1.5",
+					BaseURL:  "http://vllm.internal:8000/v1",
+				},
+			},
+			expectError:  false,
+			providerType: &OpenAIProvider{},
+		},
+		"returns error for azure deployment without api version": {
+			config: config.Config{
+				Embedding: config.EmbeddingConfig{
+					Provider: "openai",
+					Model:    "text-embedding-3-small",
+					ApiKey:   "test-key",
+					BaseURL:  "https://example.openai.azure.com",
+					Azure:    config.AzureConfig{Deployment: "embedding-small"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "azure.api_version is required",
+		},
+		"creates ollama provider successfully": {
+			config: config.Config{
+				Embedding: config.EmbeddingConfig{
+					Provider: "ollama",
+					Model:    "nomic-embed-text",
+					BaseURL:  "http://localhost:11434",
+				},
+			},
+			expectError:  false,
+			providerType: &OllamaProvider{},
+		},
+		"returns error for missing ollama model": {
+			config: config.Config{
+				Embedding: config.EmbeddingConfig{
+					Provider: "ollama",
+				},
+			},
+			expectError: true,
+			errorMsg:    "model is required for Ollama provider",
+		},
+		"creates local-hash provider without credentials": {
+			config: config.Config{
+				Embedding: config.EmbeddingConfig{
+					Provider: "local-hash",
+				},
+			},
+			expectError:  false,
+			providerType: &LocalHashProvider{},
+		},
 		"returns error for unsupported provider": {
 			config: config.Config{
 				Embedding: config.EmbeddingConfig{
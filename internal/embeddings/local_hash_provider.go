@@ -0,0 +1,116 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// DefaultLocalHashDimensions matches the width of the tools.embedding column.
+const DefaultLocalHashDimensions = 1536
+
+// Feature weights for the local hash provider. Whole words carry the most
+// signal; character trigrams make the vectors tolerant to inflections and
+// typos ("holiday" vs "holidays"), and word bigrams reward matching phrases.
+const (
+	localHashWordWeight    = 1.0
+	localHashTrigramWeight = 0.5
+	localHashBigramWeight  = 0.75
+)
+
+// LocalHashProvider implements the Provider interface without any network access.
+// It uses feature hashing of words, word bigrams and character trigrams into a
+// fixed number of dimensions. Identical text always yields the same vector, and
+// texts sharing vocabulary end up close in cosine space. It is intended for
+// tests, CI and air-gapped development, not for production-quality retrieval.
+type LocalHashProvider struct {
+	dimensions int
+}
+
+// NewLocalHashProvider creates a new local feature-hashing embedding provider.
+// A non-positive dimensions value falls back to DefaultLocalHashDimensions.
+func NewLocalHashProvider(dimensions int) *LocalHashProvider {
+	if dimensions <= 0 {
+		dimensions = DefaultLocalHashDimensions
+	}
+	return &LocalHashProvider{dimensions: dimensions}
+}
+
+// GenerateEmbedding creates an embedding vector for the given text by hashing its features.
+func (p *LocalHashProvider) GenerateEmbedding(_ context.Context, text string) ([]float32, error) {
+	words := tokenize(text)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("cannot embed text without any words")
+	}
+
+	vec := make([]float64, p.dimensions)
+	for i, word := range words {
+		p.addFeature(vec, "w:"+word, localHashWordWeight)
+
+		padded := " " + word + " "
+		runes := []rune(padded)
+		for j := 0; j+3 <= len(runes); j++ {
+			p.addFeature(vec, "c:"+string(runes[j:j+3]), localHashTrigramWeight)
+		}
+
+		if i > 0 {
+			p.addFeature(vec, "b:"+words[i-1]+" "+word, localHashBigramWeight)
+		}
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+
+	result := make([]float32, p.dimensions)
+	if norm == 0 {
+		return result, nil
+	}
+	for i, v := range vec {
+		result[i] = float32(v / norm)
+	}
+
+	return result, nil
+}
+
+// GenerateEmbeddings creates embedding vectors for several texts.
+func (p *LocalHashProvider) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	return generateSequentially(ctx, p, texts)
+}
+
+// MaxBatchSize returns the maximum number of texts accepted by GenerateEmbeddings.
+// Hashing is local and cheap, so there is no practical limit.
+func (p *LocalHashProvider) MaxBatchSize() int {
+	return math.MaxInt32
+}
+
+// GetDimensions returns the dimensionality of embeddings produced by this provider.
+func (p *LocalHashProvider) GetDimensions() int {
+	return p.dimensions
+}
+
+// addFeature hashes a feature into a bucket. A second hash bit picks the sign so
+// that collisions tend to cancel out rather than accumulate.
+func (p *LocalHashProvider) addFeature(vec []float64, feature string, weight float64) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+
+	bucket := sum % uint64(len(vec))
+	if (sum>>63)&1 == 1 {
+		weight = -weight
+	}
+	vec[bucket] += weight
+}
+
+// tokenize lowercases text and splits it into runs of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
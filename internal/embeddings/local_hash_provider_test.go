@@ -0,0 +1,82 @@
+package embeddings
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func TestLocalHashProvider_GenerateEmbedding(t *testing.T) {
+	provider := NewLocalHashProvider(0)
+	ctx := context.Background()
+
+	t.Run("uses default dimensions", func(t *testing.T) {
+		result, err := provider.GenerateEmbedding(ctx, "public holidays")
+		require.NoError(t, err)
+		assert.Len(t, result, DefaultLocalHashDimensions)
+		assert.Equal(t, DefaultLocalHashDimensions, provider.GetDimensions())
+	})
+
+	t.Run("is deterministic", func(t *testing.T) {
+		first, err := provider.GenerateEmbedding(ctx, "Retrieve public holidays for a country")
+		require.NoError(t, err)
+
+		second, err := NewLocalHashProvider(0).GenerateEmbedding(ctx, "Retrieve public holidays for a country")
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("returns unit vectors", func(t *testing.T) {
+		result, err := provider.GenerateEmbedding(ctx, "convert currency amounts")
+		require.NoError(t, err)
+
+		var norm float64
+		for _, v := range result {
+			norm += float64(v) * float64(v)
+		}
+		assert.InDelta(t, 1.0, norm, 1e-5)
+	})
+
+	t.Run("ranks similar text above unrelated text", func(t *testing.T) {
+		query, err := provider.GenerateEmbedding(ctx, "which days are public holidays in Germany")
+		require.NoError(t, err)
+
+		related, err := provider.GenerateEmbedding(ctx, "Tool: get_holidays\nDescription: Retrieve the list of all public holidays for the specified year and country")
+		require.NoError(t, err)
+
+		unrelated, err := provider.GenerateEmbedding(ctx, "Tool: convert_currency\nDescription: Convert an amount of money between two currencies")
+		require.NoError(t, err)
+
+		assert.Greater(t, cosineSimilarity(query, related), cosineSimilarity(query, unrelated))
+	})
+
+	t.Run("rejects text without words", func(t *testing.T) {
+		result, err := provider.GenerateEmbedding(ctx, "  --- ")
+		require.Error(t, err)
+		assert.Nil(t, result)
+	})
+}
+
+func TestLocalHashProvider_CustomDimensions(t *testing.T) {
+	provider := NewLocalHashProvider(64)
+
+	result, err := provider.GenerateEmbedding(context.Background(), "small vector")
+	require.NoError(t, err)
+
+	assert.Len(t, result, 64)
+	assert.Equal(t, 64, provider.GetDimensions())
+}
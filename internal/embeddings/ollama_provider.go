@@ -0,0 +1,152 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultOllamaBaseURL is the address a local Ollama daemon listens on.
+const DefaultOllamaBaseURL = "http://localhost:11434"
+
+// ollamaMaxBatchSize bounds how many texts GenerateEmbeddings accepts. The
+// /api/embeddings endpoint takes one prompt per request, so batches are
+// embedded sequentially.
+const ollamaMaxBatchSize = 32
+
+// ollamaModelDimensions lists the output size of common Ollama embedding models.
+// Models not listed here report their dimensions after the first request.
+var ollamaModelDimensions = map[string]int{
+	"nomic-embed-text":       768,
+	"mxbai-embed-large":      1024,
+	"all-minilm":             384,
+	"snowflake-arctic-embed": 1024,
+	"bge-m3":                 1024,
+	"bge-large":              1024,
+}
+
+// OllamaProvider implements the Provider interface using Ollama's embedding API.
+type OllamaProvider struct {
+	httpClient *http.Client
+	baseURL    string
+	model      string
+	dimensions atomic.Int64
+}
+
+type ollamaEmbeddingRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+type ollamaEmbeddingResponse struct {
+	Embedding []float64 `json:"embedding"`
+}
+
+type ollamaErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewOllamaProvider creates a new Ollama embedding provider.
+// An empty baseURL falls back to DefaultOllamaBaseURL.
+func NewOllamaProvider(baseURL, model string) *OllamaProvider {
+	if baseURL == "" {
+		baseURL = DefaultOllamaBaseURL
+	}
+
+	p := &OllamaProvider{
+		httpClient: http.DefaultClient,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      model,
+	}
+
+	// Model tags such as "nomic-embed-text:latest" share the base model's dimensions
+	name, _, _ := strings.Cut(model, ":")
+	if dims, ok := ollamaModelDimensions[name]; ok {
+		p.dimensions.Store(int64(dims))
+	}
+
+	return p
+}
+
+// GenerateEmbedding creates an embedding vector for the given text using Ollama's API.
+func (p *OllamaProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	payload, err := json.Marshal(ollamaEmbeddingRequest{
+		Model:  p.model,
+		Prompt: text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ollama request: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/embeddings", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ollama request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("ollama embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ollama response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		statusErr := &StatusError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Header:     resp.Header,
+		}
+		var errResp ollamaErrorResponse
+		if json.Unmarshal(body, &errResp) == nil {
+			statusErr.Message = errResp.Error
+		}
+		return nil, fmt.Errorf("ollama embedding request failed: %w", statusErr)
+	}
+
+	var embResp ollamaEmbeddingResponse
+	if err := json.Unmarshal(body, &embResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ollama response: %w", err)
+	}
+
+	if len(embResp.Embedding) == 0 {
+		return nil, fmt.Errorf("ollama returned an empty embedding")
+	}
+
+	emb32 := toFloat32(embResp.Embedding)
+
+	p.dimensions.Store(int64(len(emb32)))
+
+	return emb32, nil
+}
+
+// GenerateEmbeddings creates embedding vectors for several texts, one request per text.
+func (p *OllamaProvider) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) > ollamaMaxBatchSize {
+		return nil, fmt.Errorf("batch of %d texts exceeds maximum of %d", len(texts), ollamaMaxBatchSize)
+	}
+	return generateSequentially(ctx, p, texts)
+}
+
+// requestPerText marks the provider as sending one request per text, which rate limits count.
+func (p *OllamaProvider) requestPerText() {}
+
+// MaxBatchSize returns the maximum number of texts accepted by GenerateEmbeddings.
+func (p *OllamaProvider) MaxBatchSize() int {
+	return ollamaMaxBatchSize
+}
+
+// GetDimensions returns the dimensionality of embeddings produced by this provider.
+// For models without a known size this is 0 until the first embedding is generated.
+func (p *OllamaProvider) GetDimensions() int {
+	return int(p.dimensions.Load())
+}
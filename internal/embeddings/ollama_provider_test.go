@@ -0,0 +1,162 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockOllamaServer creates a test HTTP server that mocks Ollama API responses
+func mockOllamaServer(t *testing.T, response interface{}, statusCode int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify request
+		assert.Equal(t, "/api/embeddings", r.URL.Path)
+		assert.Equal(t, "POST", r.Method)
+
+		var req ollamaEmbeddingRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.NotEmpty(t, req.Model)
+		assert.NotEmpty(t, req.Prompt)
+
+		// Return mock response
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+// mockOllamaEmbeddingResponse creates a mock Ollama embedding API response
+func mockOllamaEmbeddingResponse(dimensions int) map[string]interface{} {
+	embedding := make([]float64, dimensions)
+	for i := range embedding {
+		embedding[i] = float64(i) / float64(dimensions)
+	}
+
+	return map[string]interface{}{
+		"embedding": embedding,
+	}
+}
+
+func TestOllamaProvider_GenerateEmbedding(t *testing.T) {
+	tests := map[string]struct {
+		mockResponse interface{}
+		statusCode   int
+		text         string
+		expectError  bool
+		errorMsg     string
+		validateFunc func(t *testing.T, result []float32)
+	}{
+		"successfully generates embedding": {
+			mockResponse: mockOllamaEmbeddingResponse(768),
+			statusCode:   http.StatusOK,
+			text:         "test text",
+			expectError:  false,
+			validateFunc: func(t *testing.T, result []float32) {
+				assert.Len(t, result, 768)
+				assert.Equal(t, float32(0.0), result[0])
+				assert.True(t, result[1] > 0)
+			},
+		},
+		"handles API error": {
+			mockResponse: map[string]interface{}{
+				"error": `model "missing-model" not found, try pulling it first`,
+			},
+			statusCode:  http.StatusNotFound,
+			text:        "test text",
+			expectError: true,
+			errorMsg:    "not found, try pulling it first",
+		},
+		"handles empty embedding": {
+			mockResponse: map[string]interface{}{
+				"embedding": []float64{},
+			},
+			statusCode:  http.StatusOK,
+			text:        "test text",
+			expectError: true,
+			errorMsg:    "ollama returned an empty embedding",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Create mock server
+			server := mockOllamaServer(t, tc.mockResponse, tc.statusCode)
+			defer server.Close()
+
+			provider := NewOllamaProvider(server.URL, "nomic-embed-text")
+
+			// Execute
+			ctx := context.Background()
+			result, err := provider.GenerateEmbedding(ctx, tc.text)
+
+			// Validate
+			if tc.expectError {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorMsg)
+				assert.Nil(t, result)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, result)
+				if tc.validateFunc != nil {
+					tc.validateFunc(t, result)
+				}
+			}
+		})
+	}
+}
+
+func TestOllamaProvider_GetDimensions(t *testing.T) {
+	tests := map[string]struct {
+		model          string
+		responseDims   int
+		expectedBefore int
+		expectedAfter  int
+	}{
+		"known model": {
+			model:          "nomic-embed-text",
+			responseDims:   768,
+			expectedBefore: 768,
+			expectedAfter:  768,
+		},
+		"known model with tag": {
+			model:          "mxbai-embed-large:latest",
+			responseDims:   1024,
+			expectedBefore: 1024,
+			expectedAfter:  1024,
+		},
+		"unknown model learns dimensions from response": {
+			model:          "custom-embedder",
+			responseDims:   512,
+			expectedBefore: 0,
+			expectedAfter:  512,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := mockOllamaServer(t, mockOllamaEmbeddingResponse(tc.responseDims), http.StatusOK)
+			defer server.Close()
+
+			provider := NewOllamaProvider(server.URL, tc.model)
+			assert.Equal(t, tc.expectedBefore, provider.GetDimensions())
+
+			_, err := provider.GenerateEmbedding(context.Background(), "test")
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedAfter, provider.GetDimensions())
+		})
+	}
+}
+
+func TestNewOllamaProvider_DefaultBaseURL(t *testing.T) {
+	provider := NewOllamaProvider("", "nomic-embed-text")
+	assert.Equal(t, DefaultOllamaBaseURL, provider.baseURL)
+
+	provider = NewOllamaProvider("http://ollama.internal:11434/", "nomic-embed-text")
+	assert.Equal(t, "http://ollama.internal:11434", provider.baseURL)
+}
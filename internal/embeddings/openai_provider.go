@@ -3,34 +3,102 @@ package embeddings
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
 
+	"github.com/ddazal/marcopolo-go/internal/config"
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
 )
 
+// openAIMaxBatchSize is the maximum number of inputs accepted by a single embeddings request.
+const openAIMaxBatchSize = 2048
+
+// openAIMaxBatchTokens bounds the estimated tokens sent in a single embeddings
+// request. The API rejects requests above 300,000 tokens; the estimate is rough,
+// so leave headroom.
+const openAIMaxBatchTokens = 200_000
+
+// openAIModelDimensions lists the default output size of OpenAI embedding models.
+var openAIModelDimensions = map[string]int{
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+}
+
 // OpenAIProvider implements the Provider interface using OpenAI's embedding API.
 type OpenAIProvider struct {
 	client openai.Client
 	model  string
+
+	// dimensions requests shortened embeddings when > 0 (text-embedding-3 models only)
+	dimensions int
+	// observedDimensions records the size of the last embedding for models not in openAIModelDimensions
+	observedDimensions atomic.Int64
 }
 
 // NewOpenAIProvider creates a new OpenAI embedding provider.
-func NewOpenAIProvider(apiKey, model string) *OpenAIProvider {
+// A dimensions value > 0 is sent to the API to request shortened embeddings;
+// 0 keeps the model's native size. Additional request options are applied
+// after the API key, so they can redirect the client to any OpenAI-compatible endpoint.
+func NewOpenAIProvider(apiKey, model string, dimensions int, opts ...option.RequestOption) *OpenAIProvider {
+	var clientOpts []option.RequestOption
+	if apiKey != "" {
+		clientOpts = append(clientOpts, option.WithAPIKey(apiKey))
+	}
+	clientOpts = append(clientOpts, opts...)
+
 	return &OpenAIProvider{
-		client: openai.NewClient(option.WithAPIKey(apiKey)),
-		model:  model,
+		client:     openai.NewClient(clientOpts...),
+		model:      model,
+		dimensions: dimensions,
+	}
+}
+
+// openAIClientOptions translates the embedding configuration into client options
+// for OpenAI-compatible backends (Azure OpenAI, vLLM, LiteLLM, ...).
+func openAIClientOptions(cfg config.EmbeddingConfig) ([]option.RequestOption, error) {
+	var opts []option.RequestOption
+
+	if cfg.Azure.Deployment != "" {
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("base_url is required when azure.deployment is set")
+		}
+		if cfg.Azure.APIVersion == "" {
+			return nil, fmt.Errorf("azure.api_version is required when azure.deployment is set")
+		}
+
+		// Azure routes by deployment in the path and authenticates with an Api-Key header
+		baseURL := strings.TrimRight(cfg.BaseURL, "/") + "/openai/deployments/" + url.PathEscape(cfg.Azure.Deployment) + "/"
+		opts = append(opts,
+			option.WithBaseURL(baseURL),
+			option.WithQuery("api-version", cfg.Azure.APIVersion),
+			option.WithHeaderDel("authorization"),
+		)
+		if cfg.ApiKey != "" {
+			opts = append(opts, option.WithHeader("Api-Key", cfg.ApiKey))
+		}
+	} else if cfg.BaseURL != "" {
+		opts = append(opts, option.WithBaseURL(cfg.BaseURL))
+	}
+
+	if cfg.Organization != "" {
+		opts = append(opts, option.WithOrganization(cfg.Organization))
+	}
+	if cfg.Project != "" {
+		opts = append(opts, option.WithProject(cfg.Project))
+	}
+	for key, value := range cfg.Headers {
+		opts = append(opts, option.WithHeader(key, value))
 	}
+
+	return opts, nil
 }
 
 // GenerateEmbedding creates an embedding vector for the given text using OpenAI's API.
 func (p *OpenAIProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
-	resp, err := p.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
-		Input: openai.EmbeddingNewParamsInputUnion{
-			OfArrayOfStrings: []string{text},
-		},
-		Model:          openai.EmbeddingModel(p.model),
-		EncodingFormat: "float",
-	})
+	resp, err := p.client.Embeddings.New(ctx, p.newParams([]string{text}))
 	if err != nil {
 		return nil, fmt.Errorf("openai embedding request failed: %w", err)
 	}
@@ -39,21 +107,119 @@ func (p *OpenAIProvider) GenerateEmbedding(ctx context.Context, text string) ([]
 		return nil, fmt.Errorf("expected 1 embedding, got %d", len(resp.Data))
 	}
 
-	// Convert []float64 to []float32
-	emb64 := resp.Data[0].Embedding
-	emb32 := make([]float32, len(emb64))
-	for i, v := range emb64 {
-		emb32[i] = float32(v)
+	embedding := toFloat32(resp.Data[0].Embedding)
+	p.observedDimensions.Store(int64(len(embedding)))
+
+	return embedding, nil
+}
+
+// GenerateEmbeddings creates embedding vectors for several texts. Texts are sent
+// in a single OpenAI request unless their estimated tokens exceed openAIMaxBatchTokens,
+// in which case they are split across several requests.
+func (p *OpenAIProvider) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+	if len(texts) > openAIMaxBatchSize {
+		return nil, fmt.Errorf("batch of %d texts exceeds maximum of %d", len(texts), openAIMaxBatchSize)
 	}
 
-	return emb32, nil
+	results := make([][]float32, 0, len(texts))
+	for _, chunk := range splitByTokens(texts, openAIMaxBatchTokens) {
+		embeddings, err := p.embedBatch(ctx, chunk)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, embeddings...)
+	}
+
+	return results, nil
+}
+
+// splitByTokens groups consecutive texts into chunks whose estimated tokens stay
+// within maxTokens. A text estimated above maxTokens gets a chunk of its own.
+func splitByTokens(texts []string, maxTokens int) [][]string {
+	var chunks [][]string
+	start, tokens := 0, 0
+	for i, text := range texts {
+		n := estimateTokens(text)
+		if i > start && tokens+n > maxTokens {
+			chunks = append(chunks, texts[start:i])
+			start, tokens = i, 0
+		}
+		tokens += n
+	}
+	return append(chunks, texts[start:])
+}
+
+// embedBatch creates embedding vectors for texts in a single OpenAI request.
+func (p *OpenAIProvider) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	resp, err := p.client.Embeddings.New(ctx, p.newParams(texts))
+	if err != nil {
+		return nil, fmt.Errorf("openai embedding request failed: %w", err)
+	}
+
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Data))
+	}
+
+	// The API reports each vector's input position; don't rely on response order
+	results := make([][]float32, len(texts))
+	for _, data := range resp.Data {
+		if data.Index < 0 || int(data.Index) >= len(texts) || results[data.Index] != nil {
+			return nil, fmt.Errorf("unexpected embedding index %d", data.Index)
+		}
+		results[data.Index] = toFloat32(data.Embedding)
+	}
+	p.observedDimensions.Store(int64(len(results[0])))
+
+	return results, nil
+}
+
+// MaxBatchSize returns the maximum number of texts accepted by GenerateEmbeddings.
+func (p *OpenAIProvider) MaxBatchSize() int {
+	return openAIMaxBatchSize
+}
+
+// maxBatchTokens marks GenerateEmbeddings as sending one request per token-limited chunk,
+// which rate limits count.
+func (p *OpenAIProvider) maxBatchTokens() int {
+	return openAIMaxBatchTokens
 }
 
 // GetDimensions returns the dimensionality of embeddings produced by this provider.
+// Returns the configured dimensions if set, otherwise the model's native size.
+// For models without a known size this is 0 until the first embedding is generated.
 func (p *OpenAIProvider) GetDimensions() int {
-	// text-embedding-3-small: 1536 dimensions
-	// text-embedding-3-large: 3072 dimensions
-	// For simplicity, return 1536 (matches current migration)
-	// TODO: Make this dynamic based on model
-	return 1536
+	if p.dimensions > 0 {
+		return p.dimensions
+	}
+	if dims, ok := openAIModelDimensions[p.model]; ok {
+		return dims
+	}
+	return int(p.observedDimensions.Load())
+}
+
+// newParams builds an embeddings request for the given inputs.
+func (p *OpenAIProvider) newParams(texts []string) openai.EmbeddingNewParams {
+	params := openai.EmbeddingNewParams{
+		Input: openai.EmbeddingNewParamsInputUnion{
+			OfArrayOfStrings: texts,
+		},
+		Model:          openai.EmbeddingModel(p.model),
+		EncodingFormat: "float",
+	}
+	if p.dimensions > 0 {
+		params.Dimensions = openai.Int(int64(p.dimensions))
+	}
+	return params
+}
+
+// toFloat32 converts an API embedding from []float64 to []float32.
+func toFloat32(emb64 []float64) []float32 {
+	emb32 := make([]float32, len(emb64))
+	for i, v := range emb64 {
+		emb32[i] = float32(v)
+	}
+	return emb32
 }
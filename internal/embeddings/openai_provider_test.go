@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/ddazal/marcopolo-go/internal/config"
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
 	"github.com/stretchr/testify/assert"
@@ -146,12 +148,69 @@ func TestOpenAIProvider_GenerateEmbedding(t *testing.T) {
 }
 
 func TestOpenAIProvider_GetDimensions(t *testing.T) {
-	provider := &OpenAIProvider{
-		model: "text-embedding-3-small",
+	tests := map[string]struct {
+		model      string
+		dimensions int
+		expected   int
+	}{
+		"text-embedding-3-small": {
+			model:    "text-embedding-3-small",
+			expected: 1536,
+		},
+		"text-embedding-3-large": {
+			model:    "text-embedding-3-large",
+			expected: 3072,
+		},
+		"configured dimensions override model default": {
+			model:      "text-embedding-3-large",
+			dimensions: 1024,
+			expected:   1024,
+		},
+		"unknown model before first request": {
+			model:    "custom-embedder",
+			expected: 0,
+		},
 	}
 
-	dimensions := provider.GetDimensions()
-	assert.Equal(t, 1536, dimensions)
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			provider := NewOpenAIProvider("test-key", tc.model, tc.dimensions)
+			assert.Equal(t, tc.expected, provider.GetDimensions())
+		})
+	}
+}
+
+func TestOpenAIProvider_SendsConfiguredDimensions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Dimensions int `json:"dimensions"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, 256, req.Dimensions)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(mockEmbeddingResponse(256))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("test-key", "text-embedding-3-large", 256, option.WithBaseURL(server.URL))
+
+	result, err := provider.GenerateEmbedding(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Len(t, result, 256)
+	assert.Equal(t, 256, provider.GetDimensions())
+}
+
+func TestOpenAIProvider_LearnsDimensionsOfUnknownModel(t *testing.T) {
+	server := mockOpenAIServer(t, mockEmbeddingResponse(384), http.StatusOK)
+	defer server.Close()
+
+	provider := NewOpenAIProvider("test-key", "custom-embedder", 0, option.WithBaseURL(server.URL))
+	assert.Equal(t, 0, provider.GetDimensions())
+
+	_, err := provider.GenerateEmbedding(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Equal(t, 384, provider.GetDimensions())
 }
 
 func TestOpenAIProvider_Float64ToFloat32Conversion(t *testing.T) {
@@ -181,3 +240,188 @@ func TestOpenAIProvider_Float64ToFloat32Conversion(t *testing.T) {
 		assert.InDelta(t, expected, v, 0.0001)
 	}
 }
+
+func TestOpenAIProvider_CompatibleEndpoints(t *testing.T) {
+	tests := map[string]struct {
+		config      config.EmbeddingConfig
+		expectPath  string
+		expectQuery string
+		expectAuth  string
+		expectHdrs  map[string]string
+	}{
+		"custom base url with headers": {
+			config: config.EmbeddingConfig{
+				ApiKey:  "gateway-key",
+				Headers: map[string]string{"X-Team": "search"},
+			},
+			expectPath: "/embeddings",
+			expectAuth: "Bearer gateway-key",
+			expectHdrs: map[string]string{"X-Team": "search"},
+		},
+		"organization and project": {
+			config: config.EmbeddingConfig{
+				ApiKey:       "test-key",
+				Organization: "org-123",
+				Project:      "proj-456",
+			},
+			expectPath: "/embeddings",
+			expectAuth: "Bearer test-key",
+			expectHdrs: map[string]string{
+				"OpenAI-Organization": "org-123",
+				"OpenAI-Project":      "proj-456",
+			},
+		},
+		"azure deployment": {
+			config: config.EmbeddingConfig{
+				ApiKey: "azure-key",
+				Azure: config.AzureConfig{
+					Deployment: "embedding-small",
+					APIVersion: "2024-06-01",
+				},
+			},
+			expectPath:  "/openai/deployments/embedding-small/embeddings",
+			expectQuery: "api-version=2024-06-01",
+			expectAuth:  "",
+			expectHdrs:  map[string]string{"Api-Key": "azure-key"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.expectPath, r.URL.Path)
+				assert.Equal(t, tc.expectQuery, r.URL.RawQuery)
+				assert.Equal(t, tc.expectAuth, r.Header.Get("Authorization"))
+				for key, value := range tc.expectHdrs {
+					assert.Equal(t, value, r.Header.Get(key))
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(mockEmbeddingResponse(8))
+			}))
+			defer server.Close()
+
+			cfg := tc.config
+			cfg.BaseURL = server.URL
+			opts, err := openAIClientOptions(cfg)
+			require.NoError(t, err)
+
+			provider := NewOpenAIProvider(cfg.ApiKey, "text-embedding-3-small", 0, opts...)
+			result, err := provider.GenerateEmbedding(context.Background(), "test")
+			require.NoError(t, err)
+			assert.Len(t, result, 8)
+		})
+	}
+}
+
+func TestOpenAIClientOptions_AzureRequiresBaseURL(t *testing.T) {
+	_, err := openAIClientOptions(config.EmbeddingConfig{
+		Azure: config.AzureConfig{Deployment: "embedding-small", APIVersion: "2024-06-01"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "base_url is required")
+}
+
+func TestOpenAIProvider_GenerateEmbeddings(t *testing.T) {
+	// Respond out of order to verify results are placed by index
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, []string{"first", "second", "third"}, req.Input)
+
+		data := []map[string]interface{}{}
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			data = append(data, map[string]interface{}{
+				"object":    "embedding",
+				"index":     i,
+				"embedding": []float64{float64(i), float64(i)},
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"object": "list",
+			"data":   data,
+			"model":  "text-embedding-3-small",
+		})
+	}))
+	defer server.Close()
+
+	provider := &OpenAIProvider{
+		client: openai.NewClient(
+			option.WithAPIKey("test-key"),
+			option.WithBaseURL(server.URL),
+		),
+		model: "text-embedding-3-small",
+	}
+
+	result, err := provider.GenerateEmbeddings(context.Background(), []string{"first", "second", "third"})
+	require.NoError(t, err)
+	require.Len(t, result, 3)
+	for i, embedding := range result {
+		assert.Equal(t, []float32{float32(i), float32(i)}, embedding)
+	}
+}
+
+func TestOpenAIProvider_GenerateEmbeddingsRejectsOversizedBatch(t *testing.T) {
+	provider := &OpenAIProvider{model: "text-embedding-3-small"}
+
+	_, err := provider.GenerateEmbeddings(context.Background(), make([]string, openAIMaxBatchSize+1))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds maximum")
+}
+
+func TestOpenAIProvider_GenerateEmbeddingsSplitsByTokens(t *testing.T) {
+	// Each text is estimated at half the token limit, so two fit in one request
+	text := strings.Repeat("a", openAIMaxBatchTokens*2-8)
+	texts := []string{text + "0", text + "1", text + "2", text + "3", text + "4"}
+
+	var requests [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req.Input)
+
+		data := []map[string]interface{}{}
+		for i, input := range req.Input {
+			data = append(data, map[string]interface{}{
+				"object":    "embedding",
+				"index":     i,
+				"embedding": []float64{float64(input[len(input)-1] - '0')},
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"object": "list",
+			"data":   data,
+			"model":  "text-embedding-3-small",
+		})
+	}))
+	defer server.Close()
+
+	provider := &OpenAIProvider{
+		client: openai.NewClient(
+			option.WithAPIKey("test-key"),
+			option.WithBaseURL(server.URL),
+		),
+		model: "text-embedding-3-small",
+	}
+
+	result, err := provider.GenerateEmbeddings(context.Background(), texts)
+	require.NoError(t, err)
+
+	require.Len(t, requests, 3)
+	assert.Equal(t, texts[:2], requests[0])
+	assert.Equal(t, texts[2:4], requests[1])
+	assert.Equal(t, texts[4:], requests[2])
+
+	require.Len(t, result, 5)
+	for i, embedding := range result {
+		assert.Equal(t, []float32{float32(i)}, embedding)
+	}
+}
@@ -1,6 +1,9 @@
 package embeddings
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 // Provider defines the interface for embedding generation services.
 type Provider interface {
@@ -8,6 +11,53 @@ type Provider interface {
 	// Returns a float32 slice representing the embedding.
 	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
 
+	// GenerateEmbeddings creates embedding vectors for several texts at once.
+	// Returns one vector per input text, in input order. Callers must not pass
+	// more than MaxBatchSize texts; use GenerateInBatches for larger inputs.
+	GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error)
+
+	// MaxBatchSize returns the maximum number of texts accepted by GenerateEmbeddings.
+	MaxBatchSize() int
+
 	// GetDimensions returns the dimensionality of embeddings produced by this provider.
 	GetDimensions() int
 }
+
+// GenerateInBatches embeds any number of texts by splitting them into chunks of
+// at most the provider's MaxBatchSize. Returns one vector per input, in input order.
+func GenerateInBatches(ctx context.Context, p Provider, texts []string) ([][]float32, error) {
+	batchSize := p.MaxBatchSize()
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+
+	results := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		end := min(start+batchSize, len(texts))
+
+		batch, err := p.GenerateEmbeddings(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", end-start, len(batch))
+		}
+
+		results = append(results, batch...)
+	}
+
+	return results, nil
+}
+
+// generateSequentially embeds texts one at a time, for providers without a batch endpoint.
+func generateSequentially(ctx context.Context, p Provider, texts []string) ([][]float32, error) {
+	results := make([][]float32, len(texts))
+	for i, text := range texts {
+		embedding, err := p.GenerateEmbedding(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = embedding
+	}
+	return results, nil
+}
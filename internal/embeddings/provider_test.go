@@ -0,0 +1,77 @@
+package embeddings
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingProvider embeds each text as its numeric value and records batch sizes
+type recordingProvider struct {
+	maxBatch int
+	batches  []int
+}
+
+func (p *recordingProvider) GenerateEmbedding(_ context.Context, text string) ([]float32, error) {
+	v, err := strconv.Atoi(text)
+	if err != nil {
+		return nil, err
+	}
+	return []float32{float32(v)}, nil
+}
+
+func (p *recordingProvider) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	p.batches = append(p.batches, len(texts))
+	return generateSequentially(ctx, p, texts)
+}
+
+func (p *recordingProvider) MaxBatchSize() int { return p.maxBatch }
+
+func (p *recordingProvider) GetDimensions() int { return 1 }
+
+func TestGenerateInBatches(t *testing.T) {
+	tests := map[string]struct {
+		maxBatch        int
+		count           int
+		expectedBatches []int
+	}{
+		"splits into provider-sized chunks": {
+			maxBatch:        3,
+			count:           7,
+			expectedBatches: []int{3, 3, 1},
+		},
+		"single batch when input fits": {
+			maxBatch:        10,
+			count:           4,
+			expectedBatches: []int{4},
+		},
+		"no requests for empty input": {
+			maxBatch:        10,
+			count:           0,
+			expectedBatches: nil,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			provider := &recordingProvider{maxBatch: tc.maxBatch}
+
+			texts := make([]string, tc.count)
+			for i := range texts {
+				texts[i] = strconv.Itoa(i)
+			}
+
+			result, err := GenerateInBatches(context.Background(), provider, texts)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedBatches, provider.batches)
+			require.Len(t, result, tc.count)
+			for i, embedding := range result {
+				assert.Equal(t, []float32{float32(i)}, embedding, "output %d out of order", i)
+			}
+		})
+	}
+}
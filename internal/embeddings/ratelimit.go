@@ -0,0 +1,152 @@
+package embeddings
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ddazal/marcopolo-go/internal/config"
+)
+
+// RateLimitedProvider wraps a Provider with client-side token buckets for
+// requests per minute and (estimated) tokens per minute, so that bulk indexing
+// stays under the API's limits instead of tripping 429s.
+type RateLimitedProvider struct {
+	Provider
+	requests *tokenBucket
+	tokens   *tokenBucket
+}
+
+// NewRateLimitedProvider creates a rate-limiting decorator around provider.
+// Limits set to zero are not enforced.
+func NewRateLimitedProvider(provider Provider, cfg config.RateLimitConfig) *RateLimitedProvider {
+	return &RateLimitedProvider{
+		Provider: provider,
+		requests: newTokenBucket(cfg.RequestsPerMinute),
+		tokens:   newTokenBucket(cfg.TokensPerMinute),
+	}
+}
+
+// GenerateEmbedding waits for capacity, then creates an embedding vector.
+func (p *RateLimitedProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if err := p.wait(ctx, estimateTokens(text)); err != nil {
+		return nil, err
+	}
+	return p.Provider.GenerateEmbedding(ctx, text)
+}
+
+// GenerateEmbeddings waits for capacity, then creates embedding vectors for several texts.
+// Providers that send one request per text are charged one request per text, and
+// providers that split batches by tokens are charged one request per split.
+func (p *RateLimitedProvider) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	if _, ok := p.Provider.(requestPerTextProvider); ok {
+		return generateSequentially(ctx, p, texts)
+	}
+
+	chunks := [][]string{texts}
+	if limited, ok := p.Provider.(tokenLimitedProvider); ok {
+		chunks = splitByTokens(texts, limited.maxBatchTokens())
+	}
+
+	results := make([][]float32, 0, len(texts))
+	for _, chunk := range chunks {
+		var tokens int
+		for _, text := range chunk {
+			tokens += estimateTokens(text)
+		}
+
+		if err := p.wait(ctx, tokens); err != nil {
+			return nil, err
+		}
+		embeddings, err := p.Provider.GenerateEmbeddings(ctx, chunk)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, embeddings...)
+	}
+
+	return results, nil
+}
+
+// requestPerTextProvider is implemented by providers without a batch endpoint,
+// whose GenerateEmbeddings sends one API request per text
+type requestPerTextProvider interface {
+	requestPerText()
+}
+
+// tokenLimitedProvider is implemented by providers whose GenerateEmbeddings
+// splits texts into one API request per chunk of splitByTokens
+type tokenLimitedProvider interface {
+	maxBatchTokens() int
+}
+
+func (p *RateLimitedProvider) wait(ctx context.Context, tokens int) error {
+	if err := p.requests.take(ctx, 1); err != nil {
+		return err
+	}
+	return p.tokens.take(ctx, tokens)
+}
+
+// estimateTokens approximates the token count of text. English averages
+// about four characters per token, which is close enough for throttling.
+func estimateTokens(text string) int {
+	return len(text)/4 + 1
+}
+
+// tokenBucket is a token bucket refilled continuously at perMinute/60 tokens
+// per second, holding at most one minute's worth of tokens.
+type tokenBucket struct {
+	mu        sync.Mutex
+	capacity  float64
+	available float64
+	perSecond float64
+	last      time.Time
+
+	// now and sleep are replaced in tests
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// newTokenBucket creates a full bucket. Returns nil (no limit) when perMinute <= 0.
+func newTokenBucket(perMinute int) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		capacity:  float64(perMinute),
+		available: float64(perMinute),
+		perSecond: float64(perMinute) / 60,
+		last:      time.Now(),
+		now:       time.Now,
+		sleep:     sleepContext,
+	}
+}
+
+// take blocks until n tokens are available or ctx is done. Requests larger
+// than the bucket are clamped to its capacity so they can eventually proceed.
+func (b *tokenBucket) take(ctx context.Context, n int) error {
+	if b == nil {
+		return nil
+	}
+
+	need := min(float64(n), b.capacity)
+	for {
+		b.mu.Lock()
+		now := b.now()
+		b.available = min(b.capacity, b.available+now.Sub(b.last).Seconds()*b.perSecond)
+		b.last = now
+
+		if b.available >= need {
+			b.available -= need
+			b.mu.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((need - b.available) / b.perSecond * float64(time.Second))
+		b.mu.Unlock()
+
+		if err := b.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package embeddings
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ddazal/marcopolo-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock drives a tokenBucket without real waiting
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) install(b *tokenBucket) {
+	b.last = c.now
+	b.now = func() time.Time { return c.now }
+	b.sleep = func(_ context.Context, d time.Duration) error {
+		c.sleeps = append(c.sleeps, d)
+		c.now = c.now.Add(d)
+		return nil
+	}
+}
+
+func TestTokenBucket_Take(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("allows a full minute of burst", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		bucket := newTokenBucket(60)
+		clock.install(bucket)
+
+		for range 60 {
+			require.NoError(t, bucket.take(ctx, 1))
+		}
+		assert.Empty(t, clock.sleeps)
+	})
+
+	t.Run("waits for refill when exhausted", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		bucket := newTokenBucket(60)
+		clock.install(bucket)
+
+		require.NoError(t, bucket.take(ctx, 60))
+		require.NoError(t, bucket.take(ctx, 2))
+
+		// 60 per minute refills one token per second
+		require.Len(t, clock.sleeps, 1)
+		assert.InDelta(t, float64(2*time.Second), float64(clock.sleeps[0]), float64(time.Millisecond))
+	})
+
+	t.Run("clamps requests larger than capacity", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		bucket := newTokenBucket(10)
+		clock.install(bucket)
+
+		require.NoError(t, bucket.take(ctx, 1000))
+		assert.Empty(t, clock.sleeps)
+	})
+
+	t.Run("nil bucket never blocks", func(t *testing.T) {
+		var bucket *tokenBucket
+		assert.NoError(t, bucket.take(ctx, 1_000_000))
+	})
+
+	t.Run("returns context error while waiting", func(t *testing.T) {
+		bucket := newTokenBucket(1)
+		require.NoError(t, bucket.take(ctx, 1))
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		assert.ErrorIs(t, bucket.take(cancelled, 1), context.Canceled)
+	})
+}
+
+// sequentialProvider sends one request per text, like Ollama
+type sequentialProvider struct {
+	countingProvider
+}
+
+func (p *sequentialProvider) requestPerText() {}
+
+// splittingProvider splits batches by tokens, like OpenAI; each one-letter text fills a request
+type splittingProvider struct {
+	countingProvider
+}
+
+func (p *splittingProvider) maxBatchTokens() int { return 1 }
+
+func TestRateLimitedProvider_ChargesRequestsPerUpstreamCall(t *testing.T) {
+	ctx := context.Background()
+	texts := []string{"a", "b", "c", "d"}
+
+	tests := map[string]struct {
+		provider   Provider
+		wantSleeps int
+	}{
+		"batch endpoint": {
+			provider:   NewLocalHashProvider(8),
+			wantSleeps: 0,
+		},
+		"one request per text": {
+			provider:   &sequentialProvider{},
+			wantSleeps: 2,
+		},
+		"split by tokens": {
+			provider:   &splittingProvider{},
+			wantSleeps: 2,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			limited := NewRateLimitedProvider(tt.provider, config.RateLimitConfig{RequestsPerMinute: 2})
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			clock.install(limited.requests)
+
+			vectors, err := limited.GenerateEmbeddings(ctx, texts)
+			require.NoError(t, err)
+			assert.Len(t, vectors, len(texts))
+			assert.Len(t, clock.sleeps, tt.wantSleeps)
+		})
+	}
+}
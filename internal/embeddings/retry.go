@@ -0,0 +1,199 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ddazal/marcopolo-go/internal/config"
+	"github.com/openai/openai-go/v3"
+)
+
+// StatusError reports a non-success HTTP response from an embedding API.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Message    string
+	Header     http.Header
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s: %s", e.Status, e.Message)
+	}
+	return e.Status
+}
+
+// RetryProvider wraps a Provider and retries transient failures (429, 5xx and
+// network errors) with exponential backoff and full jitter. A Retry-After
+// header on the failed response takes precedence over the computed backoff.
+type RetryProvider struct {
+	Provider
+	cfg config.RetryConfig
+
+	// sleep waits for d or until ctx is done; replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetryProvider creates a retrying decorator around provider.
+func NewRetryProvider(provider Provider, cfg config.RetryConfig) *RetryProvider {
+	return &RetryProvider{
+		Provider: provider,
+		cfg:      cfg,
+		sleep:    sleepContext,
+	}
+}
+
+// GenerateEmbedding creates an embedding vector, retrying transient failures.
+func (p *RetryProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	var result []float32
+	err := p.do(ctx, func() error {
+		var err error
+		result, err = p.Provider.GenerateEmbedding(ctx, text)
+		return err
+	})
+	return result, err
+}
+
+// GenerateEmbeddings creates embedding vectors for several texts, retrying transient failures.
+func (p *RetryProvider) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	var result [][]float32
+	err := p.do(ctx, func() error {
+		var err error
+		result, err = p.Provider.GenerateEmbeddings(ctx, texts)
+		return err
+	})
+	return result, err
+}
+
+func (p *RetryProvider) do(ctx context.Context, call func() error) error {
+	attempts := max(p.cfg.MaxAttempts, 1)
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if err = call(); err == nil {
+			return nil
+		}
+
+		if attempt == attempts-1 || !isRetryable(ctx, err) {
+			break
+		}
+
+		delay, ok := retryAfter(err)
+		if !ok {
+			delay = p.backoff(attempt)
+		} else if p.cfg.MaxBackoff > 0 && delay > p.cfg.MaxBackoff {
+			// Waiting that long would stall searches; fail now instead
+			return fmt.Errorf("%w (server asked to retry after %s, more than max_backoff %s)", err, delay, p.cfg.MaxBackoff)
+		}
+
+		if sleepErr := p.sleep(ctx, delay); sleepErr != nil {
+			return fmt.Errorf("%w (retry aborted: %v)", err, sleepErr)
+		}
+	}
+
+	if attempts > 1 && isRetryable(ctx, err) {
+		return fmt.Errorf("giving up after %d attempts: %w", attempts, err)
+	}
+	return err
+}
+
+// backoff returns a random delay in [0, min(MaxBackoff, InitialBackoff*2^attempt)).
+func (p *RetryProvider) backoff(attempt int) time.Duration {
+	ceiling := p.cfg.InitialBackoff << attempt
+	if ceiling <= 0 || (p.cfg.MaxBackoff > 0 && ceiling > p.cfg.MaxBackoff) {
+		ceiling = p.cfg.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+// isRetryable reports whether err is worth another attempt.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if code, ok := statusCode(err); ok {
+		return code == http.StatusRequestTimeout ||
+			code == http.StatusTooManyRequests ||
+			code >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// statusCode extracts the HTTP status of a failed API call, if any.
+func statusCode(err error) (int, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode, true
+	}
+
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode, true
+	}
+
+	return 0, false
+}
+
+// retryAfter reads the server-requested delay from a failed response.
+// Supports Retry-After-Ms as well as Retry-After in seconds or HTTP-date form.
+func retryAfter(err error) (time.Duration, bool) {
+	var header http.Header
+
+	var statusErr *StatusError
+	var apiErr *openai.Error
+	switch {
+	case errors.As(err, &statusErr):
+		header = statusErr.Header
+	case errors.As(err, &apiErr) && apiErr.Response != nil:
+		header = apiErr.Response.Header
+	}
+	if header == nil {
+		return 0, false
+	}
+
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+
+	return 0, false
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,183 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ddazal/marcopolo-go/internal/config"
+	"github.com/openai/openai-go/v3/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer fails the first `failures` requests with the given status and headers,
+// then serves a valid response produced by success.
+func flakyServer(t *testing.T, failures int32, status int, headers map[string]string, success interface{}) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if calls.Add(1) <= failures {
+			for key, value := range headers {
+				w.Header().Set(key, value)
+			}
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": "temporarily unavailable"})
+			return
+		}
+		json.NewEncoder(w).Encode(success)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// recordSleeps replaces the provider's sleep with one that records delays without waiting.
+func recordSleeps(p *RetryProvider) *[]time.Duration {
+	var delays []time.Duration
+	p.sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	return &delays
+}
+
+func TestRetryProvider_GenerateEmbedding(t *testing.T) {
+	retryCfg := config.RetryConfig{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+
+	tests := map[string]struct {
+		failures      int32
+		status        int
+		headers       map[string]string
+		expectError   bool
+		errorMsg      string
+		expectedCalls int32
+		validateSleep func(t *testing.T, delays []time.Duration)
+	}{
+		"recovers from transient 503": {
+			failures:      2,
+			status:        http.StatusServiceUnavailable,
+			expectedCalls: 3,
+			validateSleep: func(t *testing.T, delays []time.Duration) {
+				require.Len(t, delays, 2)
+				assert.Less(t, delays[0], 100*time.Millisecond)
+				assert.Less(t, delays[1], 200*time.Millisecond)
+			},
+		},
+		"honors Retry-After seconds": {
+			failures:      1,
+			status:        http.StatusTooManyRequests,
+			headers:       map[string]string{"Retry-After": "1"},
+			expectedCalls: 2,
+			validateSleep: func(t *testing.T, delays []time.Duration) {
+				assert.Equal(t, []time.Duration{time.Second}, delays)
+			},
+		},
+		"fails when Retry-After exceeds max backoff": {
+			failures:      1,
+			status:        http.StatusTooManyRequests,
+			headers:       map[string]string{"Retry-After": "3600"},
+			expectError:   true,
+			errorMsg:      "server asked to retry after 1h0m0s, more than max_backoff 1s",
+			expectedCalls: 1,
+			validateSleep: func(t *testing.T, delays []time.Duration) {
+				assert.Empty(t, delays)
+			},
+		},
+		"gives up after max attempts": {
+			failures:      5,
+			status:        http.StatusBadGateway,
+			expectError:   true,
+			errorMsg:      "giving up after 3 attempts",
+			expectedCalls: 3,
+		},
+		"does not retry client errors": {
+			failures:      1,
+			status:        http.StatusBadRequest,
+			expectError:   true,
+			errorMsg:      "400 Bad Request",
+			expectedCalls: 1,
+			validateSleep: func(t *testing.T, delays []time.Duration) {
+				assert.Empty(t, delays)
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server, calls := flakyServer(t, tc.failures, tc.status, tc.headers, mockOllamaEmbeddingResponse(8))
+
+			provider := NewRetryProvider(NewOllamaProvider(server.URL, "nomic-embed-text"), retryCfg)
+			delays := recordSleeps(provider)
+
+			result, err := provider.GenerateEmbedding(context.Background(), "test")
+
+			if tc.expectError {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorMsg)
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, result, 8)
+			}
+			assert.Equal(t, tc.expectedCalls, calls.Load())
+			if tc.validateSleep != nil {
+				tc.validateSleep(t, *delays)
+			}
+		})
+	}
+}
+
+func TestRetryProvider_OpenAIRetryAfterMs(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusTooManyRequests,
+		map[string]string{"Retry-After-Ms": "250"}, mockEmbeddingResponse(8))
+
+	base := NewOpenAIProvider("test-key", "text-embedding-3-small", 0,
+		option.WithBaseURL(server.URL), option.WithMaxRetries(0))
+	provider := NewRetryProvider(base, config.RetryConfig{MaxAttempts: 3, InitialBackoff: time.Second})
+	delays := recordSleeps(provider)
+
+	result, err := provider.GenerateEmbeddings(context.Background(), []string{"test"})
+	require.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, []time.Duration{250 * time.Millisecond}, *delays)
+}
+
+func TestRetryProvider_StopsWhenContextCancelled(t *testing.T) {
+	server, calls := flakyServer(t, 5, http.StatusServiceUnavailable, nil, mockOllamaEmbeddingResponse(8))
+
+	provider := NewRetryProvider(NewOllamaProvider(server.URL, "nomic-embed-text"),
+		config.RetryConfig{MaxAttempts: 5, InitialBackoff: time.Hour, MaxBackoff: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := provider.GenerateEmbedding(ctx, "test")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "retry aborted")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestNewProvider_WrapsRemoteProviders(t *testing.T) {
+	provider, err := NewProvider(config.Config{
+		Embedding: config.EmbeddingConfig{
+			Provider:  "ollama",
+			Model:     "nomic-embed-text",
+			Retry:     config.RetryConfig{MaxAttempts: 3},
+			RateLimit: config.RateLimitConfig{RequestsPerMinute: 60},
+		},
+	})
+	require.NoError(t, err)
+
+	retry, ok := provider.(*RetryProvider)
+	require.True(t, ok, "expected retry decorator, got %T", provider)
+	assert.IsType(t, &RateLimitedProvider{}, retry.Provider)
+	assert.Equal(t, 768, provider.GetDimensions())
+}
@@ -0,0 +1,200 @@
+// Package jsonpath evaluates a subset of JSONPath against decoded JSON values.
+//
+// Supported syntax: the root $, member access (.name, ['name'] or ["name"]),
+// array indexes ([0], negative indexes count from the end) and wildcards
+// (.* and [*]). A path without wildcards selects a single value; a path with a
+// wildcard selects a list of every match.
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	segmentMember segmentKind = iota
+	segmentIndex
+	segmentWildcard
+)
+
+type segment struct {
+	kind  segmentKind
+	name  string
+	index int
+}
+
+// Path is a compiled JSONPath expression.
+type Path struct {
+	expr     string
+	segments []segment
+	multi    bool
+}
+
+// Parse compiles a JSONPath expression.
+func Parse(expr string) (*Path, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(expr), "$")
+	if !ok {
+		return nil, fmt.Errorf("jsonpath %q must start with $", expr)
+	}
+
+	path := &Path{expr: expr}
+	for rest != "" {
+		var seg segment
+		var err error
+		switch rest[0] {
+		case '.':
+			seg, rest, err = parseDot(rest[1:])
+		case '[':
+			seg, rest, err = parseBracket(rest[1:])
+		default:
+			err = fmt.Errorf("unexpected %q", rest[0])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid jsonpath %q: %w", expr, err)
+		}
+
+		if seg.kind == segmentWildcard {
+			path.multi = true
+		}
+		path.segments = append(path.segments, seg)
+	}
+
+	return path, nil
+}
+
+func parseDot(rest string) (segment, string, error) {
+	if strings.HasPrefix(rest, "*") {
+		return segment{kind: segmentWildcard}, rest[1:], nil
+	}
+
+	end := strings.IndexAny(rest, ".[")
+	if end < 0 {
+		end = len(rest)
+	}
+	if end == 0 {
+		return segment{}, "", fmt.Errorf("empty member name")
+	}
+	return segment{kind: segmentMember, name: rest[:end]}, rest[end:], nil
+}
+
+func parseBracket(rest string) (segment, string, error) {
+	end := strings.IndexByte(rest, ']')
+	if end < 0 {
+		return segment{}, "", fmt.Errorf("unclosed [")
+	}
+	inner, rest := strings.TrimSpace(rest[:end]), rest[end+1:]
+
+	switch {
+	case inner == "*":
+		return segment{kind: segmentWildcard}, rest, nil
+	case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+		return segment{kind: segmentMember, name: inner[1 : len(inner)-1]}, rest, nil
+	}
+
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return segment{}, "", fmt.Errorf("invalid index %q", inner)
+	}
+	return segment{kind: segmentIndex, index: index}, rest, nil
+}
+
+// String returns the expression the path was parsed from.
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Get evaluates the path against a value decoded by encoding/json. A path
+// without wildcards returns an error if it matches nothing; a path with
+// wildcards returns the (possibly empty) list of matches.
+func (p *Path) Get(doc any) (any, error) {
+	nodes := []any{doc}
+	for _, seg := range p.segments {
+		var next []any
+		for _, node := range nodes {
+			matches, err := seg.apply(node)
+			if err != nil && !p.multi {
+				return nil, fmt.Errorf("jsonpath %s: %w", p.expr, err)
+			}
+			next = append(next, matches...)
+		}
+		nodes = next
+	}
+
+	if p.multi {
+		if nodes == nil {
+			nodes = []any{}
+		}
+		return nodes, nil
+	}
+	return nodes[0], nil
+}
+
+func (s segment) apply(node any) ([]any, error) {
+	switch s.kind {
+	case segmentMember:
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot select %q from %s", s.name, typeName(node))
+		}
+		value, ok := object[s.name]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", s.name)
+		}
+		return []any{value}, nil
+
+	case segmentIndex:
+		array, ok := node.([]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot index %s", typeName(node))
+		}
+		index := s.index
+		if index < 0 {
+			index += len(array)
+		}
+		if index < 0 || index >= len(array) {
+			return nil, fmt.Errorf("index %d out of range for array of %d", s.index, len(array))
+		}
+		return []any{array[index]}, nil
+
+	default:
+		switch v := node.(type) {
+		case []any:
+			return v, nil
+		case map[string]any:
+			// Members are expanded in key order, as maps are unordered
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			values := make([]any, len(keys))
+			for i, key := range keys {
+				values[i] = v[key]
+			}
+			return values, nil
+		default:
+			return nil, fmt.Errorf("cannot expand %s", typeName(node))
+		}
+	}
+}
+
+func typeName(node any) string {
+	switch node.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	default:
+		return "number"
+	}
+}
@@ -0,0 +1,110 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const document = `{
+	"data": {
+		"holidays": [
+			{"date": "2025-01-01", "name": "New Year's Day"},
+			{"date": "2025-12-25", "name": "Christmas Day"}
+		],
+		"country code": "DE",
+		"counts": {"b": 2, "a": 1}
+	}
+}`
+
+func TestPath_Get(t *testing.T) {
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(document), &doc))
+
+	tests := map[string]struct {
+		path     string
+		expected any
+	}{
+		"root": {
+			path:     "$",
+			expected: doc,
+		},
+		"member": {
+			path:     "$.data.holidays[0].name",
+			expected: "New Year's Day",
+		},
+		"quoted member": {
+			path:     "$.data['country code']",
+			expected: "DE",
+		},
+		"negative index": {
+			path:     "$.data.holidays[-1].date",
+			expected: "2025-12-25",
+		},
+		"array wildcard": {
+			path:     "$.data.holidays[*].name",
+			expected: []any{"New Year's Day", "Christmas Day"},
+		},
+		"object wildcard in key order": {
+			path:     "$.data.counts.*",
+			expected: []any{1.0, 2.0},
+		},
+		"wildcard skips missing members": {
+			path:     "$.data.holidays[*].missing",
+			expected: []any{},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			path, err := Parse(tt.path)
+			require.NoError(t, err)
+
+			value, err := path.Get(doc)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestPath_GetErrors(t *testing.T) {
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(document), &doc))
+
+	tests := map[string]struct {
+		path     string
+		expected string
+	}{
+		"missing member": {
+			path:     "$.data.missing",
+			expected: `member "missing" not found`,
+		},
+		"index out of range": {
+			path:     "$.data.holidays[2]",
+			expected: "index 2 out of range",
+		},
+		"member of array": {
+			path:     "$.data.holidays.name",
+			expected: `cannot select "name" from array`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			path, err := Parse(tt.path)
+			require.NoError(t, err)
+
+			_, err = path.Get(doc)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, expr := range []string{"data.name", "$.", "$[0", "$[x]", "$x"} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
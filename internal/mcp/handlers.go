@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 
+	"github.com/ddazal/marcopolo-go/internal/auth"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/pgvector/pgvector-go"
 )
@@ -15,12 +17,39 @@ type ToolWithScore struct {
 	Name           string
 	Description    string
 	InputSchema    *string
+	Embedding      pgvector.Vector
+	Tags           []string
+	Category       string
+	Owner          string
 	RelevanceScore float64
+	MatchedExample *string
+}
+
+// IndexedTool is what execute_tool reads from the index about a tool
+type IndexedTool struct {
+	Tags        []string
+	InputSchema *string
+}
+
+// ToolFilter restricts searches by tool metadata (matches db.ToolFilter)
+type ToolFilter struct {
+	Namespace string
+
+	IncludeTags []string
+	ExcludeTags []string
+	Category    string
+
+	Restricted   bool
+	AllowedTools []string
+	AllowedTags  []string
 }
 
 // ToolRepository defines the interface for tool database operations
 type ToolRepository interface {
-	FindSimilarWithScore(ctx context.Context, embedding pgvector.Vector, minScore float64, limit int) ([]*ToolWithScore, error)
+	FindSimilarWithScore(ctx context.Context, embedding pgvector.Vector, minScore float64, limit int, filter ToolFilter) ([]*ToolWithScore, error)
+	FindLexicalWithScore(ctx context.Context, query string, limit int, filter ToolFilter) ([]*ToolWithScore, error)
+	FindHybridWithScore(ctx context.Context, query string, embedding pgvector.Vector, minScore float64, limit int, filter ToolFilter) ([]*ToolWithScore, error)
+	GetTool(ctx context.Context, namespace, name string) (*IndexedTool, bool, error)
 }
 
 // EmbeddingProvider defines the interface for generating embeddings
@@ -28,10 +57,42 @@ type EmbeddingProvider interface {
 	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
 }
 
+// Reranker defines the interface for reordering search candidates
+type Reranker interface {
+	// Rerank returns one relevance score per document, in input order
+	Rerank(ctx context.Context, query string, documents []string) ([]float64, error)
+}
+
+// ToolProxy executes tools that are served elsewhere, such as by upstream MCP servers
+type ToolProxy interface {
+	// CallTool executes the named tool in the session's namespace (see NamespaceFromContext).
+	// handled is false when the proxy does not serve the tool.
+	CallTool(ctx context.Context, name string, arguments json.RawMessage) (result interface{}, handled bool, err error)
+}
+
 // ServerDependencies holds the dependencies needed by MCP handlers
 type ServerDependencies struct {
 	ToolRepo          ToolRepository
 	EmbeddingProvider EmbeddingProvider
+
+	// DefaultSearchMode is used when search_tools is called without a mode (default: vector)
+	DefaultSearchMode SearchMode
+
+	// Reranker reorders retrieved tools before they are truncated to max_results (nil = disabled)
+	Reranker Reranker
+	// RerankCandidates is how many tools are retrieved for reranking (at least max_results)
+	RerankCandidates int
+
+	// ToolProxy executes tools without a local handler (nil = local tools only)
+	ToolProxy ToolProxy
+}
+
+// defaultSearchMode returns the mode used when search_tools is called without one
+func (deps *ServerDependencies) defaultSearchMode() SearchMode {
+	if deps.DefaultSearchMode == "" {
+		return SearchModeVector
+	}
+	return deps.DefaultSearchMode
 }
 
 // HandleSearchTools implements the search_tools MCP tool
@@ -53,17 +114,58 @@ func (deps *ServerDependencies) HandleSearchTools(ctx context.Context, request m
 	if input.MinRelevanceScore == 0 {
 		input.MinRelevanceScore = 0.7
 	}
+	if input.Mode == "" {
+		input.Mode = deps.defaultSearchMode()
+	}
+	if !input.Mode.Valid() {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid mode %q: must be one of %v", input.Mode, SearchModes)), nil
+	}
+	if input.Diversity < 0 || input.Diversity > 1 {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid diversity %v: must be between 0 and 1", input.Diversity)), nil
+	}
 
-	// Generate embedding for query
-	queryEmbedding, err := deps.EmbeddingProvider.GenerateEmbedding(ctx, input.Query)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to generate embedding: %v", err)), nil
+	// Over-fetch when reranking or diversifying so later stages can promote tools retrieval ranked lower
+	limit := input.MaxResults
+	if deps.Reranker != nil {
+		limit = max(limit, deps.RerankCandidates)
+	}
+	if input.Diversity > 0 {
+		limit = max(limit, input.MaxResults*diversityCandidateFactor)
 	}
 
-	vec := pgvector.NewVector(queryEmbedding)
+	filter := ToolFilter{
+		Namespace:   NamespaceFromContext(ctx),
+		IncludeTags: input.IncludeTags,
+		ExcludeTags: input.ExcludeTags,
+		Category:    input.Category,
+	}
+	// Authenticated clients only see the tools their key allows
+	if access, ok := auth.FromContext(ctx); ok {
+		filter.Restricted = true
+		filter.AllowedTools = access.Tools
+		filter.AllowedTags = access.Tags
+	}
 
-	// Search for similar tools
-	dbTools, err := deps.ToolRepo.FindSimilarWithScore(ctx, vec, input.MinRelevanceScore, input.MaxResults)
+	var dbTools []*ToolWithScore
+	if input.Mode == SearchModeLexical {
+		// Lexical search needs no query embedding
+		dbTools, err = deps.ToolRepo.FindLexicalWithScore(ctx, input.Query, limit, filter)
+	} else {
+		// Generate embedding for query
+		queryEmbedding, embedErr := deps.EmbeddingProvider.GenerateEmbedding(ctx, input.Query)
+		if embedErr != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to generate embedding: %v", embedErr)), nil
+		}
+
+		vec := pgvector.NewVector(queryEmbedding)
+
+		// Search for similar tools
+		if input.Mode == SearchModeHybrid {
+			dbTools, err = deps.ToolRepo.FindHybridWithScore(ctx, input.Query, vec, input.MinRelevanceScore, limit, filter)
+		} else {
+			dbTools, err = deps.ToolRepo.FindSimilarWithScore(ctx, vec, input.MinRelevanceScore, limit, filter)
+		}
+	}
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Database search failed: %v", err)), nil
 	}
@@ -81,17 +183,38 @@ func (deps *ServerDependencies) HandleSearchTools(ctx context.Context, request m
 			Name:           dbTool.Name,
 			Description:    dbTool.Description,
 			Parameters:     params,
+			Tags:           dbTool.Tags,
+			Category:       dbTool.Category,
+			Owner:          dbTool.Owner,
 			RelevanceScore: dbTool.RelevanceScore,
+			MatchedExample: dbTool.MatchedExample,
+			embedding:      dbTool.Embedding.Slice(),
 		})
 	}
 
+	if deps.Reranker != nil {
+		if err := deps.rerank(ctx, input.Query, results); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to rerank results: %v", err)), nil
+		}
+	}
+	if input.Diversity > 0 {
+		results = diversify(results, input.Diversity, input.MaxResults)
+	}
+	if len(results) > input.MaxResults {
+		results = results[:input.MaxResults]
+	}
+
 	if len(results) == 0 {
+		if input.Mode == SearchModeLexical {
+			return mcp.NewToolResultText(fmt.Sprintf("No tools found matching: %q", input.Query)), nil
+		}
 		return mcp.NewToolResultText(fmt.Sprintf("No tools found matching: %q with minimum relevance score of %.2f", input.Query, input.MinRelevanceScore)), nil
 	}
 
 	output := SearchToolsOutput{
 		Tools: results,
 		Query: input.Query,
+		Mode:  input.Mode,
 	}
 
 	outputJSON, err := json.Marshal(output)
@@ -102,6 +225,40 @@ func (deps *ServerDependencies) HandleSearchTools(ctx context.Context, request m
 	return mcp.NewToolResultText(string(outputJSON)), nil
 }
 
+// rerank scores results with the reranker and sorts them by rerank score, keeping
+// retrieval order for ties. The retrieval score is preserved next to the rerank score.
+func (deps *ServerDependencies) rerank(ctx context.Context, query string, results []ToolSearchResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	documents := make([]string, len(results))
+	for i, result := range results {
+		documents[i] = result.Description
+	}
+
+	scores, err := deps.Reranker.Rerank(ctx, query, documents)
+	if err != nil {
+		return err
+	}
+	if len(scores) != len(results) {
+		return fmt.Errorf("expected %d rerank scores, got %d", len(results), len(scores))
+	}
+
+	for i := range results {
+		retrievalScore, rerankScore := results[i].RelevanceScore, scores[i]
+		results[i].RetrievalScore = &retrievalScore
+		results[i].RerankScore = &rerankScore
+		results[i].RelevanceScore = rerankScore
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].RelevanceScore > results[j].RelevanceScore
+	})
+
+	return nil
+}
+
 // HandleExecuteTool implements the execute_tool MCP tool
 func (deps *ServerDependencies) HandleExecuteTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var input ExecuteToolInput
@@ -114,17 +271,47 @@ func (deps *ServerDependencies) HandleExecuteTool(ctx context.Context, request m
 		return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments: %v", err)), nil
 	}
 
-	// Lookup tool handler in executable registry. Exec if it exists.
-	handler, exists := GetExecutableTool(input.ToolName)
-	if !exists {
+	// Only tools indexed in the session's namespace run, as only they can be found
+	tool, found, err := deps.ToolRepo.GetTool(ctx, NamespaceFromContext(ctx), input.ToolName)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to look up tool: %v", err)), nil
+	}
+	if !allowed(ctx, input.ToolName, tool) {
+		return mcp.NewToolResultError(fmt.Sprintf("Access denied: this API key may not use %s", input.ToolName)), nil
+	}
+	if !found {
 		return mcp.NewToolResultError(fmt.Sprintf("Tool not found: %s", input.ToolName)), nil
 	}
 
-	result, err := handler(ctx, input.Arguments)
+	// Check the arguments against the parameters search_tools returned, so the
+	// model gets every mistake at once instead of an odd error from the tool
+	if schema := tool.InputSchema; schema != nil {
+		violations, err := validateArguments(json.RawMessage(*schema), input.Arguments)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to validate arguments: %v", err)), nil
+		}
+		if len(violations) > 0 {
+			return invalidArgumentsResult(input.ToolName, json.RawMessage(*schema), violations), nil
+		}
+	}
+
+	// Lookup tool handler in executable registry. Exec if it exists, else try the proxy.
+	var result interface{}
+	if handler, exists := GetExecutableTool(input.ToolName); exists {
+		result, err = handler(ctx, input.Arguments)
+	} else {
+		handled := false
+		if deps.ToolProxy != nil {
+			result, handled, err = deps.ToolProxy.CallTool(ctx, input.ToolName, input.Arguments)
+		}
+		if !handled {
+			return mcp.NewToolResultError(fmt.Sprintf("Tool not found: %s", input.ToolName)), nil
+		}
+	}
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Tool execution failed: %v", err)), nil
 	}
-	
+
 	output := ExecuteToolOutput{Result: result}
 	outputJSON, err := json.Marshal(output)
 	if err != nil {
@@ -133,3 +320,33 @@ func (deps *ServerDependencies) HandleExecuteTool(ctx context.Context, request m
 
 	return mcp.NewToolResultText(string(outputJSON)), nil
 }
+
+// invalidArgumentsResult reports argument violations as a JSON error result
+func invalidArgumentsResult(toolName string, schema json.RawMessage, violations []ArgumentViolation) *mcp.CallToolResult {
+	output := InvalidArgumentsOutput{
+		Error:      "invalid_arguments",
+		Tool:       toolName,
+		Violations: violations,
+		Parameters: schema,
+		Hint:       "Fix every violation and call execute_tool again with arguments matching parameters.",
+	}
+	outputJSON, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments for %s", toolName))
+	}
+	return mcp.NewToolResultError(string(outputJSON))
+}
+
+// allowed reports whether the client may execute the named tool. Tags come from
+// the tool's row in the session's namespace, as in search; a tool that is not
+// indexed (nil) is only allowed by name.
+func allowed(ctx context.Context, name string, tool *IndexedTool) bool {
+	access, ok := auth.FromContext(ctx)
+	if !ok || access.AllowsName(name) {
+		return true
+	}
+	if tool == nil {
+		return false
+	}
+	return access.Allows(name, tool.Tags)
+}
@@ -0,0 +1,201 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ddazal/marcopolo-go/internal/auth"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pgvector/pgvector-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubToolRepository records the last search filter and serves tags and input schemas from maps
+type stubToolRepository struct {
+	filter  ToolFilter
+	tags    map[string][]string
+	schemas map[string]string
+}
+
+func (r *stubToolRepository) FindSimilarWithScore(_ context.Context, _ pgvector.Vector, _ float64, _ int, filter ToolFilter) ([]*ToolWithScore, error) {
+	r.filter = filter
+	return nil, nil
+}
+
+func (r *stubToolRepository) FindLexicalWithScore(_ context.Context, _ string, _ int, filter ToolFilter) ([]*ToolWithScore, error) {
+	r.filter = filter
+	return nil, nil
+}
+
+func (r *stubToolRepository) FindHybridWithScore(_ context.Context, _ string, _ pgvector.Vector, _ float64, _ int, filter ToolFilter) ([]*ToolWithScore, error) {
+	r.filter = filter
+	return nil, nil
+}
+
+// GetTool finds tools that have tags or a schema in the maps
+func (r *stubToolRepository) GetTool(_ context.Context, _, name string) (*IndexedTool, bool, error) {
+	tags, tagged := r.tags[name]
+	schema, hasSchema := r.schemas[name]
+	if !tagged && !hasSchema {
+		return nil, false, nil
+	}
+	tool := &IndexedTool{Tags: tags}
+	if hasSchema {
+		tool.InputSchema = &schema
+	}
+	return tool, true, nil
+}
+
+func callTool(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), ctx context.Context, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+	result, err := handler(ctx, request)
+	require.NoError(t, err)
+	return result
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	if len(result.Content) == 0 {
+		return ""
+	}
+	text, _ := mcp.AsTextContent(result.Content[0])
+	if text == nil {
+		return ""
+	}
+	return text.Text
+}
+
+func TestHandleSearchToolsAccessFilter(t *testing.T) {
+	repo := &stubToolRepository{}
+	deps := &ServerDependencies{ToolRepo: repo, DefaultSearchMode: SearchModeLexical}
+	args := map[string]any{"query": "holidays", "include_tags": []string{"calendar"}}
+
+	callTool(t, deps.HandleSearchTools, context.Background(), args)
+	assert.False(t, repo.filter.Restricted, "unauthenticated requests see every tool")
+	assert.Equal(t, []string{"calendar"}, repo.filter.IncludeTags)
+
+	access := &auth.Access{Tools: []string{"github.*"}, Tags: []string{"calendar"}}
+	callTool(t, deps.HandleSearchTools, auth.WithAccess(context.Background(), access), args)
+	assert.True(t, repo.filter.Restricted)
+	assert.Equal(t, []string{"github.*"}, repo.filter.AllowedTools)
+	assert.Equal(t, []string{"calendar"}, repo.filter.AllowedTags)
+	assert.Equal(t, []string{"calendar"}, repo.filter.IncludeTags)
+
+	callTool(t, deps.HandleSearchTools, WithNamespace(context.Background(), "acme"), args)
+	assert.Equal(t, "acme", repo.filter.Namespace)
+}
+
+func TestHandleExecuteToolAccess(t *testing.T) {
+	RegisterExecutable("test_access_echo", func(_ context.Context, arguments json.RawMessage) (interface{}, error) {
+		return "ok", nil
+	})
+	RegisterExecutable("test_access_other", func(_ context.Context, arguments json.RawMessage) (interface{}, error) {
+		return "ok", nil
+	})
+
+	deps := &ServerDependencies{ToolRepo: &stubToolRepository{tags: map[string][]string{
+		"test_access_echo":  {"echo"},
+		"test_access_other": {"other"},
+	}}}
+
+	tests := map[string]struct {
+		access     *auth.Access
+		tool       string
+		wantDenied bool
+	}{
+		"unauthenticated": {
+			tool: "test_access_other",
+		},
+		"allowed by name": {
+			access: &auth.Access{Tools: []string{"test_access_*"}},
+			tool:   "test_access_other",
+		},
+		"allowed by tag": {
+			access: &auth.Access{Tags: []string{"echo"}},
+			tool:   "test_access_echo",
+		},
+		"denied": {
+			access:     &auth.Access{Tools: []string{"test_access_echo"}, Tags: []string{"echo"}},
+			tool:       "test_access_other",
+			wantDenied: true,
+		},
+		"denied when not indexed": {
+			access:     &auth.Access{Tags: []string{"echo"}},
+			tool:       "test_access_missing",
+			wantDenied: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.access != nil {
+				ctx = auth.WithAccess(ctx, tt.access)
+			}
+
+			result := callTool(t, deps.HandleExecuteTool, ctx, map[string]any{"tool_name": tt.tool, "arguments": map[string]any{}})
+
+			if tt.wantDenied {
+				assert.True(t, result.IsError)
+				assert.Contains(t, resultText(result), "Access denied")
+				return
+			}
+			assert.False(t, result.IsError, resultText(result))
+		})
+	}
+}
+
+func TestHandleExecuteToolNotIndexed(t *testing.T) {
+	calls := 0
+	RegisterExecutable("test_unindexed", func(_ context.Context, arguments json.RawMessage) (interface{}, error) {
+		calls++
+		return "ok", nil
+	})
+	deps := &ServerDependencies{ToolRepo: &stubToolRepository{}}
+
+	result := callTool(t, deps.HandleExecuteTool, context.Background(), map[string]any{"tool_name": "test_unindexed"})
+
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(result), "Tool not found: test_unindexed")
+	assert.Zero(t, calls, "tools missing from the namespace's index must not run")
+}
+
+func TestHandleExecuteToolValidatesArguments(t *testing.T) {
+	calls := 0
+	RegisterExecutable("test_validate_holidays", func(_ context.Context, arguments json.RawMessage) (interface{}, error) {
+		calls++
+		return "ok", nil
+	})
+
+	schema := `{"properties":{"year":{"type":"string"},"countryCode":{"type":"string"}},"required":["year","countryCode"]}`
+	deps := &ServerDependencies{ToolRepo: &stubToolRepository{schemas: map[string]string{"test_validate_holidays": schema}}}
+
+	result := callTool(t, deps.HandleExecuteTool, context.Background(), map[string]any{
+		"tool_name": "test_validate_holidays",
+		"arguments": map[string]any{"countryCode": 49},
+	})
+	require.True(t, result.IsError)
+	assert.Zero(t, calls, "the tool must not run with invalid arguments")
+
+	var output InvalidArgumentsOutput
+	require.NoError(t, json.Unmarshal([]byte(resultText(result)), &output))
+	assert.Equal(t, "invalid_arguments", output.Error)
+	assert.Equal(t, "test_validate_holidays", output.Tool)
+	assert.Equal(t, []ArgumentViolation{
+		{Path: "countryCode", Message: "must be string, got integer"},
+		{Path: "year", Message: "is required (string)"},
+	}, output.Violations)
+	assert.JSONEq(t, schema, string(output.Parameters))
+	assert.NotEmpty(t, output.Hint)
+
+	result = callTool(t, deps.HandleExecuteTool, context.Background(), map[string]any{
+		"tool_name": "test_validate_holidays",
+		"arguments": map[string]any{"year": "2025", "countryCode": "DE"},
+	})
+	assert.False(t, result.IsError, resultText(result))
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,91 @@
+package mcp
+
+import "math"
+
+// diversityCandidateFactor controls how many candidates are retrieved for
+// diversification, relative to max_results.
+const diversityCandidateFactor = 4
+
+// diversify picks up to k results by maximal marginal relevance. Each pick maximizes
+//
+//	(1-diversity)*relevance - diversity*max(similarity to already picked results)
+//
+// where similarity is the cosine of the stored tool embeddings. With diversity 0
+// this is plain relevance order. Results must be sorted by relevance; relevance is
+// their RelevanceScore, which is the rerank score when results were reranked,
+// min-max normalized over the results. Lexical, hybrid and rerank scores have
+// their own scales, so normalizing keeps diversity meaning the same in every mode.
+func diversify(results []ToolSearchResult, diversity float64, k int) []ToolSearchResult {
+	if k > len(results) {
+		k = len(results)
+	}
+	relevance := normalizedRelevance(results)
+
+	picked := make([]ToolSearchResult, 0, k)
+	remaining := append([]ToolSearchResult(nil), results...)
+	remainingRelevance := append([]float64(nil), relevance...)
+
+	// maxSimilarity[i] is remaining[i]'s highest similarity to any picked result
+	maxSimilarity := make([]float64, len(remaining))
+
+	for len(picked) < k {
+		best, bestScore := 0, math.Inf(-1)
+		for i := range remaining {
+			score := (1-diversity)*remainingRelevance[i] - diversity*maxSimilarity[i]
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		chosen := remaining[best]
+		picked = append(picked, chosen)
+		remaining = append(remaining[:best], remaining[best+1:]...)
+		remainingRelevance = append(remainingRelevance[:best], remainingRelevance[best+1:]...)
+		maxSimilarity = append(maxSimilarity[:best], maxSimilarity[best+1:]...)
+
+		for i, candidate := range remaining {
+			maxSimilarity[i] = max(maxSimilarity[i], cosineSimilarity(candidate.embedding, chosen.embedding))
+		}
+	}
+
+	return picked
+}
+
+// normalizedRelevance scales the results' relevance scores to [0, 1]: the best
+// becomes 1 and the worst 0. Equal scores all become 1.
+func normalizedRelevance(results []ToolSearchResult) []float64 {
+	lowest, highest := math.Inf(1), math.Inf(-1)
+	for _, result := range results {
+		lowest = min(lowest, result.RelevanceScore)
+		highest = max(highest, result.RelevanceScore)
+	}
+
+	relevance := make([]float64, len(results))
+	for i, result := range results {
+		if highest > lowest {
+			relevance[i] = (result.RelevanceScore - lowest) / (highest - lowest)
+		} else {
+			relevance[i] = 1
+		}
+	}
+	return relevance
+}
+
+// cosineSimilarity returns the cosine of two vectors, or 0 if either is empty
+// or their lengths differ.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func resultNames(results []ToolSearchResult) []string {
+	names := make([]string, len(results))
+	for i, r := range results {
+		names[i] = r.Name
+	}
+	return names
+}
+
+func TestDiversify(t *testing.T) {
+	// Two near-duplicate variants, a distinct tool and a barely relevant one, sorted by relevance
+	results := []ToolSearchResult{
+		{Name: "holidays_v2", RelevanceScore: 0.92, embedding: []float32{1, 0, 0}},
+		{Name: "holidays_v1", RelevanceScore: 0.91, embedding: []float32{0.99, 0.1, 0}},
+		{Name: "calendar", RelevanceScore: 0.85, embedding: []float32{0.6, 0.8, 0}},
+		{Name: "weather", RelevanceScore: 0.40, embedding: []float32{0, 0, 1}},
+	}
+
+	tests := map[string]struct {
+		diversity float64
+		k         int
+		expected  []string
+	}{
+		"zero diversity keeps relevance order": {
+			diversity: 0,
+			k:         4,
+			expected:  []string{"holidays_v2", "holidays_v1", "calendar", "weather"},
+		},
+		"diversity promotes distinct tools over near-duplicates": {
+			diversity: 0.3,
+			k:         2,
+			expected:  []string{"holidays_v2", "calendar"},
+		},
+		"k larger than the candidates returns all of them": {
+			diversity: 0.3,
+			k:         10,
+			expected:  []string{"holidays_v2", "calendar", "holidays_v1", "weather"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, resultNames(diversify(results, tc.diversity, tc.k)))
+		})
+	}
+
+	// The input order is left untouched
+	assert.Equal(t, []string{"holidays_v2", "holidays_v1", "calendar", "weather"}, resultNames(results))
+}
+
+func TestDiversify_LexicalScores(t *testing.T) {
+	// ts_rank_cd scores are far below cosine similarities; unnormalized, the
+	// similarity penalty would push the barely relevant tool ahead of calendar
+	results := []ToolSearchResult{
+		{Name: "holidays_v2", RelevanceScore: 0.092, embedding: []float32{1, 0, 0}},
+		{Name: "holidays_v1", RelevanceScore: 0.091, embedding: []float32{0.99, 0.1, 0}},
+		{Name: "calendar", RelevanceScore: 0.085, embedding: []float32{0.6, 0.8, 0}},
+		{Name: "weather", RelevanceScore: 0.040, embedding: []float32{0, 0, 1}},
+	}
+
+	assert.Equal(t, []string{"holidays_v2", "calendar"}, resultNames(diversify(results, 0.3, 2)))
+}
+
+func TestNormalizedRelevance(t *testing.T) {
+	assert.InDeltaSlice(t, []float64{1, 0.5, 0}, normalizedRelevance([]ToolSearchResult{
+		{RelevanceScore: 0.03}, {RelevanceScore: 0.02}, {RelevanceScore: 0.01},
+	}), 1e-9)
+	assert.Equal(t, []float64{1, 1}, normalizedRelevance([]ToolSearchResult{
+		{RelevanceScore: 0.5}, {RelevanceScore: 0.5},
+	}))
+}
+
+func TestDiversify_MissingEmbeddings(t *testing.T) {
+	results := []ToolSearchResult{
+		{Name: "first", RelevanceScore: 0.9},
+		{Name: "second", RelevanceScore: 0.8},
+	}
+
+	// Without embeddings there is nothing to penalize, so relevance order is kept
+	assert.Equal(t, []string{"first", "second"}, resultNames(diversify(results, 0.5, 2)))
+}
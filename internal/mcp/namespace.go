@@ -0,0 +1,75 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/ddazal/marcopolo-go/internal/auth"
+)
+
+// NamespaceHeader selects the namespace of an HTTP request. Requests authenticated
+// with an API key may only name the key's own namespace.
+const NamespaceHeader = "X-Marcopolo-Namespace"
+
+var namespacePattern = regexp.MustCompile(`^[a-z0-9_-]*$`)
+
+// ValidNamespace reports whether ns is a valid namespace name: lowercase letters,
+// digits, "_" and "-". The empty name is the default namespace.
+func ValidNamespace(ns string) bool {
+	return namespacePattern.MatchString(ns)
+}
+
+type namespaceKey struct{}
+
+// WithNamespace returns a context whose session sees the tools of namespace ns.
+func WithNamespace(ctx context.Context, ns string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, ns)
+}
+
+// NamespaceFromContext returns the session's namespace, "" (the default
+// namespace) when none was set.
+func NamespaceFromContext(ctx context.Context) string {
+	ns, _ := ctx.Value(namespaceKey{}).(string)
+	return ns
+}
+
+// namespaceMiddleware resolves the namespace of each request: the API key's
+// namespace for authenticated requests, else the NamespaceHeader, else defaultNamespace.
+// Requests resolving to a namespace outside known are rejected, unless known is nil.
+func namespaceMiddleware(defaultNamespace string, known []string, next http.Handler) http.Handler {
+	var configured map[string]bool
+	if known != nil {
+		configured = make(map[string]bool, len(known))
+		for _, ns := range known {
+			configured[ns] = true
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ns := defaultNamespace
+		header := r.Header.Get(NamespaceHeader)
+
+		if access, ok := auth.FromContext(r.Context()); ok {
+			if header != "" && header != access.Namespace {
+				http.Error(w, fmt.Sprintf("API key may not use namespace %q", header), http.StatusForbidden)
+				return
+			}
+			ns = access.Namespace
+		} else if header != "" {
+			if !ValidNamespace(header) {
+				http.Error(w, fmt.Sprintf("invalid namespace %q", header), http.StatusBadRequest)
+				return
+			}
+			ns = header
+		}
+
+		if configured != nil && !configured[ns] {
+			http.Error(w, fmt.Sprintf("unknown namespace %q", ns), http.StatusNotFound)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithNamespace(r.Context(), ns)))
+	})
+}
@@ -0,0 +1,117 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ddazal/marcopolo-go/internal/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidNamespace(t *testing.T) {
+	for _, ns := range []string{"", "acme", "team-a", "team_2"} {
+		assert.True(t, ValidNamespace(ns), ns)
+	}
+	for _, ns := range []string{"Acme", "team a", "team.a", "../acme"} {
+		assert.False(t, ValidNamespace(ns), ns)
+	}
+}
+
+func TestNamespaceMiddleware(t *testing.T) {
+	handler := namespaceMiddleware("default-team", []string{"", "default-team", "acme", "globex"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("namespace=" + NamespaceFromContext(r.Context())))
+	}))
+
+	tests := map[string]struct {
+		access     *auth.Access
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		"default": {
+			wantStatus: http.StatusOK,
+			wantBody:   "namespace=default-team",
+		},
+		"header": {
+			header:     "acme",
+			wantStatus: http.StatusOK,
+			wantBody:   "namespace=acme",
+		},
+		"invalid header": {
+			header:     "Acme Corp",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid namespace",
+		},
+		"key namespace": {
+			access:     &auth.Access{Namespace: "acme"},
+			wantStatus: http.StatusOK,
+			wantBody:   "namespace=acme",
+		},
+		"key in default namespace": {
+			access:     &auth.Access{},
+			wantStatus: http.StatusOK,
+			wantBody:   "namespace=",
+		},
+		"header matching key": {
+			access:     &auth.Access{Namespace: "acme"},
+			header:     "acme",
+			wantStatus: http.StatusOK,
+			wantBody:   "namespace=acme",
+		},
+		"unknown header": {
+			header:     "initech",
+			wantStatus: http.StatusNotFound,
+			wantBody:   "unknown namespace",
+		},
+		"key of an unknown namespace": {
+			access:     &auth.Access{Namespace: "initech"},
+			wantStatus: http.StatusNotFound,
+			wantBody:   "unknown namespace",
+		},
+		"header of another namespace": {
+			access:     &auth.Access{Namespace: "acme"},
+			header:     "globex",
+			wantStatus: http.StatusForbidden,
+			wantBody:   "may not use namespace",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			if tt.access != nil {
+				req = req.WithContext(auth.WithAccess(req.Context(), tt.access))
+			}
+			if tt.header != "" {
+				req.Header.Set(NamespaceHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestNamespaceMiddlewareWithoutKnownNamespaces(t *testing.T) {
+	handler := namespaceMiddleware("", nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("namespace=" + NamespaceFromContext(r.Context())))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set(NamespaceHeader, "initech")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "namespace=initech", rec.Body.String())
+}
+
+func TestNamespaceFromContext(t *testing.T) {
+	assert.Equal(t, "", NamespaceFromContext(context.Background()))
+	assert.Equal(t, "acme", NamespaceFromContext(WithNamespace(context.Background(), "acme")))
+}
@@ -2,11 +2,65 @@ package mcp
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
 
+	"github.com/ddazal/marcopolo-go/internal/auth"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
+// Transport selects how the server exchanges messages with clients
+type Transport string
+
+const (
+	TransportStdio Transport = "stdio" // A single client over stdin/stdout
+	TransportHTTP  Transport = "http"  // Streamable HTTP at /mcp
+	TransportSSE   Transport = "sse"   // HTTP with server-sent events at /sse and /message
+)
+
+// Transports lists the supported transports
+var Transports = []Transport{TransportStdio, TransportHTTP, TransportSSE}
+
+// Valid reports whether t is a supported transport
+func (t Transport) Valid() bool {
+	for _, transport := range Transports {
+		if t == transport {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeOptions configures how Serve accepts clients
+type ServeOptions struct {
+	Transport Transport
+	Addr      string // Listen address of the HTTP transports, e.g. ":8080"
+	BaseURL   string // Public URL the sse transport advertises to clients ("" = relative paths)
+
+	// Namespace is the namespace of stdio sessions and of HTTP requests that
+	// neither use an API key nor send NamespaceHeader ("" = default namespace)
+	Namespace string
+
+	// Namespaces lists the namespaces HTTP requests may resolve to; requests for
+	// another are rejected with 404 (nil = any valid namespace)
+	Namespaces []string
+
+	// KeyStore authenticates clients of the HTTP transports by API key (nil = no authentication)
+	KeyStore auth.KeyStore
+
+	// ShutdownTimeout is how long open requests may run once ctx is done (0 = 10s)
+	ShutdownTimeout time.Duration
+}
+
+// DefaultShutdownTimeout bounds graceful shutdown when ServeOptions.ShutdownTimeout is not set
+const DefaultShutdownTimeout = 10 * time.Second
+
 type Server struct {
 	mcpServer *server.MCPServer
 	deps      *ServerDependencies
@@ -30,7 +84,21 @@ func NewServer(deps *ServerDependencies) *Server {
 		mcp.WithNumber("max_results",
 			mcp.Description("Maximum number of results to return (default: 5)")),
 		mcp.WithNumber("min_relevance_score",
-			mcp.Description("Minimum relevance score threshold 0-1 (default: 0.7)")),
+			mcp.Description("Minimum relevance score threshold 0-1 (default: 0.7). In hybrid mode it applies to the semantic matches only; lexical mode ignores it.")),
+		mcp.WithString("mode",
+			mcp.Enum(string(SearchModeLexical), string(SearchModeVector), string(SearchModeHybrid)),
+			mcp.Description("How to rank tools: \"lexical\" matches exact words such as tool names and codes, \"vector\" uses semantic similarity, \"hybrid\" combines both (default: "+string(deps.defaultSearchMode())+")")),
+		mcp.WithNumber("diversity",
+			mcp.Min(0), mcp.Max(1),
+			mcp.Description("Trade relevance for variety, 0-1 (default: 0). Higher values skip tools that are near-duplicates of ones already returned, such as versioned or per-region variants; 0.3 is a good start.")),
+		mcp.WithArray("include_tags",
+			mcp.WithStringItems(),
+			mcp.Description("Only return tools that have at least one of these tags, e.g. [\"calendar\"]")),
+		mcp.WithArray("exclude_tags",
+			mcp.WithStringItems(),
+			mcp.Description("Never return tools that have any of these tags, e.g. [\"deprecated\"]")),
+		mcp.WithString("category",
+			mcp.Description("Only return tools in this category, e.g. \"finance\"")),
 	)
 	mcpServer.AddTool(searchToolDef, deps.HandleSearchTools)
 
@@ -53,7 +121,86 @@ func NewServer(deps *ServerDependencies) *Server {
 	}
 }
 
-// Serve starts the MCP server using stdio transport
-func (s *Server) Serve(_ context.Context) error {
-	return server.ServeStdio(s.mcpServer)
+// Serve serves clients over the selected transport until ctx is done, then
+// shuts down gracefully.
+func (s *Server) Serve(ctx context.Context, opts ServeOptions) error {
+	switch opts.Transport {
+	case TransportStdio, "":
+		err := server.NewStdioServer(s.mcpServer).Listen(WithNamespace(ctx, opts.Namespace), os.Stdin, os.Stdout)
+		if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+			return nil
+		}
+		return err
+	case TransportHTTP, TransportSSE:
+		ln, err := net.Listen("tcp", opts.Addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", opts.Addr, err)
+		}
+		return s.serveHTTP(ctx, ln, opts)
+	default:
+		return fmt.Errorf("invalid transport %q: must be one of %v", opts.Transport, Transports)
+	}
+}
+
+// serveHTTP serves an HTTP transport on ln. On shutdown, new connections are
+// refused and open requests get ShutdownTimeout to finish before they are cut off.
+func (s *Server) serveHTTP(ctx context.Context, ln net.Listener, opts ServeOptions) error {
+	httpServer := &http.Server{}
+
+	var shutdown func(context.Context) error
+	var endpoint string
+	switch opts.Transport {
+	case TransportHTTP:
+		streamable := server.NewStreamableHTTPServer(s.mcpServer, server.WithStreamableHTTPServer(httpServer))
+		mux := http.NewServeMux()
+		mux.Handle("/mcp", streamable)
+		httpServer.Handler = mux
+		shutdown = streamable.Shutdown
+		endpoint = "/mcp"
+	case TransportSSE:
+		sse := server.NewSSEServer(s.mcpServer, server.WithHTTPServer(httpServer), server.WithBaseURL(opts.BaseURL))
+		httpServer.Handler = sse
+		// Ends the open event streams, which would otherwise hold shutdown until the timeout
+		shutdown = sse.Shutdown
+		endpoint = "/sse"
+	default:
+		return fmt.Errorf("transport %q does not serve HTTP", opts.Transport)
+	}
+
+	// The namespace depends on the API key, so it is resolved after authentication
+	httpServer.Handler = namespaceMiddleware(opts.Namespace, opts.Namespaces, httpServer.Handler)
+	if opts.KeyStore != nil {
+		httpServer.Handler = auth.Middleware(opts.KeyStore, httpServer.Handler)
+	}
+
+	log.Printf("MCP server listening on http://%s%s (%s)", ln.Addr(), endpoint, opts.Transport)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- httpServer.Serve(ln)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	timeout := opts.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	log.Printf("shutting down MCP server")
+	if err := shutdown(shutdownCtx); err != nil {
+		log.Printf("graceful shutdown did not finish: %v; closing open connections", err)
+		httpServer.Close()
+	}
+
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
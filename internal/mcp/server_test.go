@@ -0,0 +1,130 @@
+package mcp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ddazal/marcopolo-go/internal/auth"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportValid(t *testing.T) {
+	for _, tr := range Transports {
+		assert.True(t, tr.Valid(), tr)
+	}
+	assert.False(t, Transport("websocket").Valid())
+	assert.False(t, Transport("").Valid())
+}
+
+func TestServeHTTP(t *testing.T) {
+	tests := map[string]struct {
+		transport Transport
+		connect   func(baseURL string) (*client.Client, error)
+	}{
+		"streamable http": {
+			transport: TransportHTTP,
+			connect: func(baseURL string) (*client.Client, error) {
+				return client.NewStreamableHttpClient(baseURL + "/mcp")
+			},
+		},
+		"sse": {
+			transport: TransportSSE,
+			connect: func(baseURL string) (*client.Client, error) {
+				return client.NewSSEMCPClient(baseURL + "/sse")
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			server := NewServer(&ServerDependencies{})
+			served := make(chan error, 1)
+			go func() {
+				served <- server.serveHTTP(ctx, ln, ServeOptions{Transport: tt.transport, ShutdownTimeout: time.Second})
+			}()
+
+			c, err := tt.connect("http://" + ln.Addr().String())
+			require.NoError(t, err)
+			defer c.Close()
+			require.NoError(t, c.Start(ctx))
+
+			initRequest := mcp.InitializeRequest{}
+			initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+			initRequest.Params.ClientInfo = mcp.Implementation{Name: "test", Version: "1.0.0"}
+			_, err = c.Initialize(ctx, initRequest)
+			require.NoError(t, err)
+
+			result, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+			require.NoError(t, err)
+			var names []string
+			for _, tool := range result.Tools {
+				names = append(names, tool.Name)
+			}
+			assert.ElementsMatch(t, []string{"search_tools", "execute_tool"}, names)
+
+			// Cancelling the context shuts the server down, even with a client connected
+			cancel()
+			select {
+			case err := <-served:
+				assert.NoError(t, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("server did not shut down")
+			}
+
+			_, err = net.Dial("tcp", ln.Addr().String())
+			assert.Error(t, err, "listener should be closed")
+		})
+	}
+}
+
+type stubKeyStore map[string]*auth.Access
+
+func (s stubKeyStore) Lookup(_ context.Context, keyHash string) (*auth.Access, bool, error) {
+	access, ok := s[keyHash]
+	return access, ok, nil
+}
+
+func TestServeHTTPRequiresAPIKey(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := NewServer(&ServerDependencies{})
+	opts := ServeOptions{
+		Transport: TransportHTTP,
+		KeyStore:  stubKeyStore{auth.HashKey("mp_test"): {KeyName: "test", Tools: []string{"*"}}},
+	}
+	go server.serveHTTP(ctx, ln, opts)
+
+	url := "http://" + ln.Addr().String() + "/mcp"
+	resp, err := http.Post(url, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	c, err := client.NewStreamableHttpClient(url, transport.WithHTTPHeaders(map[string]string{"Authorization": "Bearer mp_test"}))
+	require.NoError(t, err)
+	defer c.Close()
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "test", Version: "1.0.0"}
+	_, err = c.Initialize(ctx, initRequest)
+	require.NoError(t, err)
+}
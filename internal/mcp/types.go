@@ -4,17 +4,45 @@ import (
 	"encoding/json"
 )
 
+// SearchMode selects how search_tools ranks tools
+type SearchMode string
+
+const (
+	SearchModeLexical SearchMode = "lexical" // Full-text rank on names and descriptions
+	SearchModeVector  SearchMode = "vector"  // Cosine similarity of embeddings
+	SearchModeHybrid  SearchMode = "hybrid"  // Reciprocal rank fusion of lexical and vector ranks
+)
+
+// SearchModes lists the supported search modes
+var SearchModes = []SearchMode{SearchModeLexical, SearchModeVector, SearchModeHybrid}
+
+// Valid reports whether m is a supported search mode
+func (m SearchMode) Valid() bool {
+	for _, mode := range SearchModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
 // SearchToolsInput defines the input for search_tools MCP tool
 type SearchToolsInput struct {
-	Query             string  `json:"query"`
-	MaxResults        int     `json:"max_results,omitempty"`         // default: 5
-	MinRelevanceScore float64 `json:"min_relevance_score,omitempty"` // default: 0.7
+	Query             string     `json:"query"`
+	MaxResults        int        `json:"max_results,omitempty"`         // default: 5
+	MinRelevanceScore float64    `json:"min_relevance_score,omitempty"` // default: 0.7, ignored in lexical mode
+	Mode              SearchMode `json:"mode,omitempty"`                // default: server's configured mode
+	Diversity         float64    `json:"diversity,omitempty"`           // 0-1, default: 0 (relevance only)
+	IncludeTags       []string   `json:"include_tags,omitempty"`        // Match tools with any of these tags
+	ExcludeTags       []string   `json:"exclude_tags,omitempty"`        // Skip tools with any of these tags
+	Category          string     `json:"category,omitempty"`            // Match tools in this category
 }
 
 // SearchToolsOutput is the response from search_tools
 type SearchToolsOutput struct {
 	Tools []ToolSearchResult `json:"tools"`
 	Query string             `json:"query"`
+	Mode  SearchMode         `json:"mode"`
 }
 
 // ExecuteToolInput defines the input for execute_tool MCP tool
@@ -28,10 +56,36 @@ type ExecuteToolOutput struct {
 	Result interface{} `json:"result"`
 }
 
-// ToolSearchResult represents a tool with its relevance score
+// InvalidArgumentsOutput is the error result of execute_tool when the arguments
+// do not match the tool's parameters. The tool is not called.
+type InvalidArgumentsOutput struct {
+	Error      string              `json:"error"` // Always "invalid_arguments"
+	Tool       string              `json:"tool"`
+	Violations []ArgumentViolation `json:"violations"`
+	Parameters json.RawMessage     `json:"parameters"` // The tool's parameter schema, as search_tools returns it
+	Hint       string              `json:"hint"`
+}
+
+// ArgumentViolation is one way the arguments break the tool's parameter schema
+type ArgumentViolation struct {
+	Path    string `json:"path"` // Argument name, e.g. "year", "filter.status" or "ids[0]"; "" for the arguments object
+	Message string `json:"message"`
+}
+
+// ToolSearchResult represents a tool with its relevance score.
+// When results are reranked, RelevanceScore is the rerank score and both
+// stage scores are reported separately.
 type ToolSearchResult struct {
 	Name           string          `json:"name"`
 	Description    string          `json:"description"`
 	Parameters     json.RawMessage `json:"parameters,omitempty"`
+	Tags           []string        `json:"tags,omitempty"`
+	Category       string          `json:"category,omitempty"`
+	Owner          string          `json:"owner,omitempty"`
 	RelevanceScore float64         `json:"relevance_score"`
+	RetrievalScore *float64        `json:"retrieval_score,omitempty"`
+	RerankScore    *float64        `json:"rerank_score,omitempty"`
+	MatchedExample *string         `json:"matched_example,omitempty"` // Example query that matched best, if any
+
+	embedding []float32 // Stored tool embedding, used for diversification
 }
@@ -0,0 +1,204 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// validateArguments checks tool arguments against the tool's input schema and
+// returns every violation, sorted by path. Only the keywords that tool parameters
+// use are checked: type, required, enum, properties and items. Missing arguments
+// are an empty object.
+func validateArguments(schema, arguments json.RawMessage) ([]ArgumentViolation, error) {
+	var root map[string]any
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return nil, fmt.Errorf("invalid input schema: %w", err)
+	}
+
+	var value any = map[string]any{}
+	if trimmed := bytes.TrimSpace(arguments); len(trimmed) > 0 && !bytes.Equal(trimmed, []byte("null")) {
+		if err := json.Unmarshal(trimmed, &value); err != nil {
+			return []ArgumentViolation{{Message: fmt.Sprintf("arguments are not valid JSON: %v", err)}}, nil
+		}
+	}
+
+	// Parameters omit the type of the arguments object
+	if _, ok := root["type"]; !ok {
+		root["type"] = "object"
+	}
+
+	var violations []ArgumentViolation
+	validateValue(root, value, "", &violations)
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Path < violations[j].Path
+	})
+	return violations, nil
+}
+
+func validateValue(schema map[string]any, value any, path string, violations *[]ArgumentViolation) {
+	report := func(format string, args ...any) {
+		*violations = append(*violations, ArgumentViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if types := schemaTypes(schema); len(types) > 0 && !matchesAnyType(value, types) {
+		report("must be %s, got %s", strings.Join(types, " or "), jsonType(value))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 && !inEnum(value, enum) {
+		allowed, _ := json.Marshal(enum)
+		got, _ := json.Marshal(value)
+		report("must be one of %s, got %s", allowed, got)
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]any); ok {
+			for _, item := range required {
+				name, ok := item.(string)
+				if !ok {
+					continue
+				}
+				if _, present := v[name]; !present {
+					*violations = append(*violations, ArgumentViolation{
+						Path:    joinPath(path, name),
+						Message: "is required" + describeProperty(properties[name]),
+					})
+				}
+			}
+		}
+		for name, propValue := range v {
+			if propSchema, ok := properties[name].(map[string]any); ok {
+				validateValue(propSchema, propValue, joinPath(path, name), violations)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				validateValue(items, item, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+	}
+}
+
+// schemaTypes returns the types a schema allows, none if it does not say
+func schemaTypes(schema map[string]any) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var types []string
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func matchesAnyType(value any, types []string) bool {
+	for _, t := range types {
+		if matchesType(value, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesType reports whether a decoded JSON value has the JSON Schema type t.
+// Unknown types match anything, so unusual schemas do not block calls.
+func matchesType(value any, t string) bool {
+	switch t {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	default:
+		return "null"
+	}
+}
+
+// inEnum reports whether value is one of enum. Parameters list enum values as
+// strings, so a value also matches its string form, e.g. 2 matches "2".
+func inEnum(value any, enum []any) bool {
+	for _, allowed := range enum {
+		if reflect.DeepEqual(value, allowed) {
+			return true
+		}
+		if s, ok := allowed.(string); ok && value != nil && fmt.Sprint(value) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// describeProperty summarizes a missing property's schema, so the model knows what to send
+func describeProperty(node any) string {
+	schema, ok := node.(map[string]any)
+	if !ok {
+		return ""
+	}
+	var parts []string
+	if types := schemaTypes(schema); len(types) > 0 {
+		parts = append(parts, strings.Join(types, " or "))
+	}
+	if description, ok := schema["description"].(string); ok && description != "" {
+		parts = append(parts, strings.TrimSpace(description))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ": ") + ")"
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
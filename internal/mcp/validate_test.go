@@ -0,0 +1,106 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateArguments(t *testing.T) {
+	// Parameters as index stores them: no top-level type, enums as strings
+	parameters := `{
+		"properties": {
+			"year": {"type": "string", "description": "Target year"},
+			"countryCode": {"type": "string", "enum": ["DE", "ES"]},
+			"limit": {"type": "integer"},
+			"version": {"type": "integer", "enum": ["1", "2"]}
+		},
+		"required": ["year", "countryCode"]
+	}`
+	nested := `{
+		"type": "object",
+		"properties": {
+			"filter": {
+				"type": "object",
+				"properties": {"status": {"type": "string", "enum": ["open", "closed"]}},
+				"required": ["status"]
+			},
+			"ids": {"type": "array", "items": {"type": "integer"}},
+			"note": {"type": ["string", "null"]}
+		}
+	}`
+
+	tests := map[string]struct {
+		schema    string
+		arguments string
+		want      []ArgumentViolation
+	}{
+		"valid": {
+			schema:    parameters,
+			arguments: `{"year": "2025", "countryCode": "DE", "limit": 3, "version": 2}`,
+		},
+		"unknown arguments are allowed": {
+			schema:    parameters,
+			arguments: `{"year": "2025", "countryCode": "DE", "verbose": true}`,
+		},
+		"every violation": {
+			schema:    parameters,
+			arguments: `{"countryCode": "FR", "limit": 2.5, "version": 3}`,
+			want: []ArgumentViolation{
+				{Path: "countryCode", Message: `must be one of ["DE","ES"], got "FR"`},
+				{Path: "limit", Message: "must be integer, got number"},
+				{Path: "version", Message: `must be one of ["1","2"], got 3`},
+				{Path: "year", Message: "is required (string: Target year)"},
+			},
+		},
+		"wrong type": {
+			schema:    parameters,
+			arguments: `{"year": 2025, "countryCode": "DE"}`,
+			want:      []ArgumentViolation{{Path: "year", Message: "must be string, got integer"}},
+		},
+		"missing arguments": {
+			schema: parameters,
+			want: []ArgumentViolation{
+				{Path: "countryCode", Message: "is required (string)"},
+				{Path: "year", Message: "is required (string: Target year)"},
+			},
+		},
+		"arguments not an object": {
+			schema:    parameters,
+			arguments: `["2025", "DE"]`,
+			want:      []ArgumentViolation{{Message: "must be object, got array"}},
+		},
+		"nested": {
+			schema:    nested,
+			arguments: `{"filter": {"status": "pending"}, "ids": [1, "2"], "note": null}`,
+			want: []ArgumentViolation{
+				{Path: "filter.status", Message: `must be one of ["open","closed"], got "pending"`},
+				{Path: "ids[1]", Message: "must be integer, got string"},
+			},
+		},
+		"nested required": {
+			schema:    nested,
+			arguments: `{"filter": {}}`,
+			want:      []ArgumentViolation{{Path: "filter.status", Message: "is required (string)"}},
+		},
+		"empty schema": {
+			schema:    `{}`,
+			arguments: `{"anything": 1}`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			violations, err := validateArguments(json.RawMessage(tt.schema), json.RawMessage(tt.arguments))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, violations)
+		})
+	}
+}
+
+func TestValidateArgumentsInvalidSchema(t *testing.T) {
+	_, err := validateArguments(json.RawMessage(`"not a schema"`), json.RawMessage(`{}`))
+	assert.Error(t, err)
+}
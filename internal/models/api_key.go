@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// APIKey is a key that authenticates clients of the HTTP transports.
+// The key itself is never stored, only its hash.
+type APIKey struct {
+	ID         int64      `json:"id" db:"id"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"` // First characters of the key, for display
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Namespace  string     `json:"namespace" db:"namespace"` // Namespace the key's clients see ("" = default)
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+
+	// Allowlist: the key may use tools whose name matches one of AllowedTools
+	// ("*" matches any characters) or that have one of AllowedTags
+	AllowedTools StringList `json:"allowed_tools" db:"allowed_tools"`
+	AllowedTags  StringList `json:"allowed_tags" db:"allowed_tags"`
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/ddazal/marcopolo-go/internal/tools"
@@ -18,14 +21,87 @@ type Tool struct {
 	Description string          `json:"description" db:"description"`
 	Embedding   pgvector.Vector `json:"embedding" db:"embedding"`
 	InputSchema *string         `json:"input_schema,omitempty" db:"input_schema"` // JSON string
+
+	// Index state, used to skip re-embedding unchanged tools
+	ContentHash       string `json:"content_hash" db:"content_hash"`
+	EmbeddingProvider string `json:"embedding_provider" db:"embedding_provider"`
+	EmbeddingModel    string `json:"embedding_model" db:"embedding_model"`
+	EmbeddingTemplate string `json:"embedding_template" db:"embedding_template"` // Version of the template that built Description
+
+	// Metadata used to filter searches
+	Tags     StringList `json:"tags" db:"tags"`
+	Category string     `json:"category" db:"category"`
+	Owner    string     `json:"owner" db:"owner"`
+
+	// Source is the upstream MCP server the tool is proxied to ("" = local)
+	Source string `json:"source" db:"source"`
+
+	// Namespace is the catalog the tool belongs to ("" = default namespace)
+	Namespace string `json:"namespace" db:"namespace"`
+
+	// Examples are the texts of the tool's example embeddings. They are loaded by
+	// listing queries and written separately from the tool row.
+	Examples StringList `json:"examples,omitempty" db:"examples"`
+}
+
+// EmbeddingKindExample marks a ToolEmbedding of an example user query.
+const EmbeddingKindExample = "example"
+
+// ToolEmbedding is an additional embedding of a tool, stored in tool_embeddings.
+type ToolEmbedding struct {
+	ID        int64           `json:"id" db:"id"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+	ToolID    int64           `json:"tool_id" db:"tool_id"`
+	Kind      string          `json:"kind" db:"kind"`
+	Text      string          `json:"text" db:"text"`
+	Embedding pgvector.Vector `json:"embedding" db:"embedding"`
+}
+
+// StringList is a list of strings stored as a jsonb array.
+type StringList []string
+
+// Value encodes the list as a JSON array; a nil list is stored as an empty array.
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]string(l))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan decodes a JSON array.
+func (l *StringList) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", src)
+	}
+	return json.Unmarshal(data, (*[]string)(l))
 }
 
 // NewTool creates a Tool entity from a ToolDefinition and embedding.
 func NewTool(def tools.ToolDefinition, description tools.ToolDescription, embedding pgvector.Vector) *Tool {
 	return &Tool{
-		Name:        def.Name,
-		Description: description.Text,
-		Embedding:   embedding,
-		InputSchema: description.InputSchema,
+		Name:              def.Name,
+		Description:       description.Text,
+		Embedding:         embedding,
+		InputSchema:       description.InputSchema,
+		ContentHash:       description.ContentHash(),
+		EmbeddingTemplate: description.TemplateVersion,
+		Examples:          description.Examples,
+		Tags:              def.Tags,
+		Category:          def.Category,
+		Owner:             def.Owner,
+		Source:            def.Source,
 	}
 }
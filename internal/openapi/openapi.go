@@ -0,0 +1,578 @@
+// Package openapi generates tool definitions from OpenAPI 3 specs. Each operation
+// becomes a tool with an http executor, written as a definition file that
+// index and serve load from tools_dir.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ddazal/marcopolo-go/internal/tools"
+	"go.yaml.in/yaml/v3"
+)
+
+// methods are the operation keys of a path item, in output order
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Options control which operations are imported and how their tools call them.
+type Options struct {
+	// BaseURL is prepended to operation paths (default: the first server URL)
+	BaseURL string
+
+	// Include keeps only operations matching one of the filters. Exclude drops
+	// operations matching any. A filter starting with / matches paths, where *
+	// matches any characters; other filters match tags.
+	Include []string
+	Exclude []string
+
+	// Category and Owner are set on every generated tool
+	Category string
+	Owner    string
+}
+
+// Result holds the generated tools and the operations that were skipped.
+type Result struct {
+	Tools    []tools.FileDefinition
+	Warnings []string
+}
+
+// spec is a parsed OpenAPI document. Values are kept generic so that $ref can
+// point anywhere in the document.
+type spec struct {
+	root map[string]any
+}
+
+// Import converts the operations of an OpenAPI 3 document (YAML or JSON) into
+// tool definitions. Operations that cannot be expressed as a tool are skipped
+// with a warning.
+func Import(data []byte, opts Options) (*Result, error) {
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	root, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("spec must be an object")
+	}
+	if version, _ := root["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("unsupported spec version %q: only OpenAPI 3 is supported", version)
+	}
+	s := &spec{root: root}
+
+	baseURL := opts.BaseURL
+	if baseURL == "" {
+		baseURL = s.serverURL()
+	}
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		return nil, fmt.Errorf("spec has no absolute server URL: a base URL is required")
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	include, err := compileFilters(opts.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compileFilters(opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, _ := root["paths"].(map[string]any)
+	pathNames := make([]string, 0, len(paths))
+	for path := range paths {
+		pathNames = append(pathNames, path)
+	}
+	sort.Strings(pathNames)
+
+	result := &Result{}
+	seen := make(map[string]string)
+	for _, path := range pathNames {
+		item, err := s.object(paths[path])
+		if err != nil {
+			result.warnf("%s: %v", path, err)
+			continue
+		}
+
+		for _, method := range methods {
+			operation, ok := item[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			op := newOperation(method, path, item, operation)
+			if !op.selected(include, exclude) {
+				continue
+			}
+
+			def, err := s.toolDefinition(op, baseURL, opts)
+			if err != nil {
+				result.warnf("%s: skipped: %v", op, err)
+				continue
+			}
+			if other, exists := seen[def.Name]; exists {
+				result.warnf("%s: skipped: tool name %q is already used by %s", op, def.Name, other)
+				continue
+			}
+			seen[def.Name] = op.String()
+			result.Tools = append(result.Tools, def)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *Result) warnf(format string, args ...any) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (s *spec) serverURL() string {
+	servers, _ := s.root["servers"].([]any)
+	if len(servers) == 0 {
+		return ""
+	}
+	server, _ := servers[0].(map[string]any)
+	url, _ := server["url"].(string)
+	return url
+}
+
+// resolve follows local $ref pointers such as #/components/schemas/Pet
+func (s *spec) resolve(node any) (any, error) {
+	for range 32 {
+		object, ok := node.(map[string]any)
+		if !ok {
+			return node, nil
+		}
+		ref, ok := object["$ref"].(string)
+		if !ok {
+			return node, nil
+		}
+
+		pointer, ok := strings.CutPrefix(ref, "#/")
+		if !ok {
+			return nil, fmt.Errorf("unsupported $ref %q: only local references are supported", ref)
+		}
+		node = s.root
+		for _, token := range strings.Split(pointer, "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			parent, ok := node.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("unresolved $ref %q", ref)
+			}
+			if node, ok = parent[token]; !ok {
+				return nil, fmt.Errorf("unresolved $ref %q", ref)
+			}
+		}
+	}
+	return nil, fmt.Errorf("$ref chain too long")
+}
+
+// object resolves node and requires it to be an object
+func (s *spec) object(node any) (map[string]any, error) {
+	resolved, err := s.resolve(node)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := resolved.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an object")
+	}
+	return object, nil
+}
+
+// operation is one method of a path item
+type operation struct {
+	method    string
+	path      string
+	item      map[string]any
+	operation map[string]any
+	tags      []string
+}
+
+func newOperation(method, path string, item, op map[string]any) *operation {
+	o := &operation{method: method, path: path, item: item, operation: op}
+	tags, _ := op["tags"].([]any)
+	for _, tag := range tags {
+		if s, ok := tag.(string); ok && strings.TrimSpace(s) != "" {
+			o.tags = append(o.tags, s)
+		}
+	}
+	return o
+}
+
+func (o *operation) String() string {
+	return strings.ToUpper(o.method) + " " + o.path
+}
+
+// filter matches an operation by tag, or by path when it starts with /
+type filter struct {
+	tag  string
+	path *regexp.Regexp
+}
+
+func compileFilters(values []string) ([]filter, error) {
+	filters := make([]filter, 0, len(values))
+	for _, value := range values {
+		if !strings.HasPrefix(value, "/") {
+			filters = append(filters, filter{tag: value})
+			continue
+		}
+		parts := strings.Split(value, "*")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		re, err := regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid path filter %q: %w", value, err)
+		}
+		filters = append(filters, filter{path: re})
+	}
+	return filters, nil
+}
+
+func (f filter) matches(o *operation) bool {
+	if f.path != nil {
+		return f.path.MatchString(o.path)
+	}
+	for _, tag := range o.tags {
+		if tag == f.tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *operation) selected(include, exclude []filter) bool {
+	for _, f := range exclude {
+		if f.matches(o) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, f := range include {
+		if f.matches(o) {
+			return true
+		}
+	}
+	return false
+}
+
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// toolName uses the operationId, or derives a name from the method and path
+func (o *operation) toolName() string {
+	if id, _ := o.operation["operationId"].(string); strings.TrimSpace(id) != "" {
+		return strings.Trim(invalidNameChars.ReplaceAllString(id, "_"), "_")
+	}
+	name := o.method + strings.NewReplacer("{", "", "}", "").Replace(o.path)
+	return strings.Trim(invalidNameChars.ReplaceAllString(name, "_"), "_")
+}
+
+func (o *operation) description() string {
+	summary, _ := o.operation["summary"].(string)
+	description, _ := o.operation["description"].(string)
+	parts := make([]string, 0, 2)
+	for _, part := range []string{summary, description} {
+		if part = strings.TrimSpace(part); part != "" && !slices.Contains(parts, part) {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return o.String()
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// parameters merges path item and operation parameters; operation parameters
+// override path item parameters with the same name and location
+func (s *spec) parameters(o *operation) ([]map[string]any, error) {
+	var merged []map[string]any
+	index := make(map[string]int)
+	for _, source := range []map[string]any{o.item, o.operation} {
+		list, _ := source["parameters"].([]any)
+		for _, node := range list {
+			param, err := s.object(node)
+			if err != nil {
+				return nil, fmt.Errorf("parameter: %w", err)
+			}
+			name, _ := param["name"].(string)
+			in, _ := param["in"].(string)
+			if name == "" || in == "" {
+				return nil, fmt.Errorf("parameter without name or location")
+			}
+
+			key := in + ":" + name
+			if i, exists := index[key]; exists {
+				merged[i] = param
+				continue
+			}
+			index[key] = len(merged)
+			merged = append(merged, param)
+		}
+	}
+	return merged, nil
+}
+
+func (s *spec) toolDefinition(o *operation, baseURL string, opts Options) (tools.FileDefinition, error) {
+	def := tools.ToolDefinition{
+		Name:        o.toolName(),
+		Description: o.description(),
+		Parameters:  &tools.Parameters{Properties: map[string]tools.ParameterProperty{}, Required: []string{}},
+		Tags:        o.tags,
+		Category:    opts.Category,
+		Owner:       opts.Owner,
+	}
+	executor := tools.HTTPExecutorSpec{
+		Type:   "http",
+		Method: strings.ToUpper(o.method),
+		URL:    baseURL + pathTemplate(o.path),
+	}
+
+	params, err := s.parameters(o)
+	if err != nil {
+		return tools.FileDefinition{}, err
+	}
+	for _, param := range params {
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		required, _ := param["required"].(bool)
+
+		switch in {
+		case "path":
+			required = true
+		case "query":
+			executor.Query = setTemplate(executor.Query, name)
+		case "header":
+			executor.Headers = setTemplate(executor.Headers, name)
+		default:
+			if required {
+				return tools.FileDefinition{}, fmt.Errorf("required %s parameter %q is not supported", in, name)
+			}
+			continue
+		}
+
+		if _, exists := def.Parameters.Properties[name]; exists {
+			return tools.FileDefinition{}, fmt.Errorf("parameter %q is defined in more than one location", name)
+		}
+		prop, err := s.property(param["schema"], param["description"])
+		if err != nil {
+			return tools.FileDefinition{}, fmt.Errorf("parameter %q: %w", name, err)
+		}
+		def.Parameters.Properties[name] = prop
+		if required {
+			def.Parameters.Required = append(def.Parameters.Required, name)
+		}
+	}
+
+	if err := s.requestBody(o, &def, &executor); err != nil {
+		return tools.FileDefinition{}, err
+	}
+
+	if len(def.Parameters.Properties) == 0 {
+		def.Parameters = nil
+	}
+	if err := def.Validate(); err != nil {
+		return tools.FileDefinition{}, err
+	}
+	if _, err := tools.NewHTTPExecutor(def, executor); err != nil {
+		return tools.FileDefinition{}, err
+	}
+
+	raw, err := json.Marshal(executor)
+	if err != nil {
+		return tools.FileDefinition{}, err
+	}
+	return tools.FileDefinition{ToolDefinition: def, Executor: raw}, nil
+}
+
+// requestBody adds the JSON body of an operation to the tool. The properties of
+// an object body become parameters; any other body is a single "body" parameter.
+func (s *spec) requestBody(o *operation, def *tools.ToolDefinition, executor *tools.HTTPExecutorSpec) error {
+	node, ok := o.operation["requestBody"]
+	if !ok {
+		return nil
+	}
+	body, err := s.object(node)
+	if err != nil {
+		return fmt.Errorf("request body: %w", err)
+	}
+	required, _ := body["required"].(bool)
+
+	content, _ := body["content"].(map[string]any)
+	media, ok := jsonMedia(content)
+	if !ok {
+		if required {
+			return fmt.Errorf("request body has no JSON media type")
+		}
+		return nil
+	}
+
+	schema, err := s.object(media["schema"])
+	if err != nil {
+		return fmt.Errorf("request body schema: %w", err)
+	}
+
+	properties, isObject := schema["properties"].(map[string]any)
+	if !isObject || tools.PropertyFromSchema(schema).Type != "object" {
+		if _, exists := def.Parameters.Properties["body"]; exists {
+			return fmt.Errorf("parameter %q is defined in more than one location", "body")
+		}
+		prop, err := s.property(schema, body["description"])
+		if err != nil {
+			return fmt.Errorf("request body: %w", err)
+		}
+		def.Parameters.Properties["body"] = prop
+		if required {
+			def.Parameters.Required = append(def.Parameters.Required, "body")
+		}
+		executor.Body = "{{json .body}}"
+		return nil
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	requiredProps := make(map[string]bool)
+	if list, ok := schema["required"].([]any); ok {
+		for _, item := range list {
+			if name, ok := item.(string); ok {
+				requiredProps[name] = true
+			}
+		}
+	}
+
+	for _, name := range names {
+		if _, exists := def.Parameters.Properties[name]; exists {
+			return fmt.Errorf("parameter %q is defined in more than one location", name)
+		}
+		prop, err := s.property(properties[name], nil)
+		if err != nil {
+			return fmt.Errorf("body property %q: %w", name, err)
+		}
+		def.Parameters.Properties[name] = prop
+		// A property is only required when the body itself is
+		if required && requiredProps[name] {
+			def.Parameters.Required = append(def.Parameters.Required, name)
+		}
+		executor.BodyArguments = append(executor.BodyArguments, name)
+	}
+	return nil
+}
+
+// jsonMedia picks the JSON media type of a content map
+func jsonMedia(content map[string]any) (map[string]any, bool) {
+	if media, ok := content["application/json"].(map[string]any); ok {
+		return media, true
+	}
+	types := make([]string, 0, len(content))
+	for mediaType := range content {
+		types = append(types, mediaType)
+	}
+	sort.Strings(types)
+	for _, mediaType := range types {
+		if strings.HasSuffix(strings.SplitN(mediaType, ";", 2)[0], "+json") {
+			media, ok := content[mediaType].(map[string]any)
+			return media, ok
+		}
+	}
+	return nil, false
+}
+
+// property converts a schema to a tool parameter. A description given next to
+// the schema, as parameters have, takes precedence over the schema's own.
+func (s *spec) property(node any, description any) (tools.ParameterProperty, error) {
+	schema := map[string]any{}
+	if node != nil {
+		var err error
+		if schema, err = s.object(node); err != nil {
+			return tools.ParameterProperty{}, err
+		}
+	}
+
+	schema, err := s.inline(schema, maxSchemaDepth)
+	if err != nil {
+		return tools.ParameterProperty{}, err
+	}
+
+	prop := tools.PropertyFromSchema(schema)
+	if d, ok := description.(string); ok && strings.TrimSpace(d) != "" {
+		prop.Description = strings.TrimSpace(d)
+	}
+	return prop, nil
+}
+
+// maxSchemaDepth bounds how deep inline follows items and properties, so
+// recursive schemas such as trees end in an untyped leaf
+const maxSchemaDepth = 8
+
+// inline returns a copy of schema whose items and properties have their $refs resolved
+func (s *spec) inline(schema map[string]any, depth int) (map[string]any, error) {
+	inlined := make(map[string]any, len(schema))
+	for key, value := range schema {
+		inlined[key] = value
+	}
+	if depth == 0 {
+		delete(inlined, "items")
+		delete(inlined, "properties")
+		return inlined, nil
+	}
+
+	if node, ok := schema["items"]; ok {
+		items, err := s.object(node)
+		if err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+		if inlined["items"], err = s.inline(items, depth-1); err != nil {
+			return nil, err
+		}
+	}
+	if properties, ok := schema["properties"].(map[string]any); ok {
+		resolved := make(map[string]any, len(properties))
+		for name, node := range properties {
+			property, err := s.object(node)
+			if err != nil {
+				return nil, fmt.Errorf("property %q: %w", name, err)
+			}
+			if resolved[name], err = s.inline(property, depth-1); err != nil {
+				return nil, err
+			}
+		}
+		inlined["properties"] = resolved
+	}
+	return inlined, nil
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// pathTemplate turns an OpenAPI path such as /pets/{petId} into a URL template
+func pathTemplate(path string) string {
+	return pathParam.ReplaceAllStringFunc(path, func(match string) string {
+		return argumentTemplate(match[1 : len(match)-1])
+	})
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// argumentTemplate references an argument in a template. Names that are not
+// identifiers, such as X-Request-Id, need the index function.
+func argumentTemplate(name string) string {
+	if identifier.MatchString(name) {
+		return "{{." + name + "}}"
+	}
+	return fmt.Sprintf("{{index . %q}}", name)
+}
+
+func setTemplate(templates map[string]string, name string) map[string]string {
+	if templates == nil {
+		templates = make(map[string]string)
+	}
+	templates[name] = argumentTemplate(name)
+	return templates
+}
@@ -0,0 +1,208 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ddazal/marcopolo-go/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petstore = `
+openapi: 3.0.3
+info:
+  title: Petstore
+  version: 1.0.0
+servers:
+  - url: https://petstore.example.com/v1
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List all pets
+      tags: [pets]
+      parameters:
+        - name: limit
+          in: query
+          description: How many pets to return
+          schema: {type: integer, default: 20}
+        - name: X-Request-Id
+          in: header
+          schema: {type: string}
+    post:
+      operationId: createPet
+      summary: Create a pet
+      tags: [pets]
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/NewPet'
+  /pets/{petId}:
+    parameters:
+      - $ref: '#/components/parameters/PetId'
+    get:
+      summary: Info for a specific pet
+      description: Returns the pet with the given ID.
+      tags: [pets]
+    delete:
+      operationId: deletePet
+      description: Delete a pet
+      tags: [admin]
+  /upload:
+    post:
+      operationId: uploadPhoto
+      summary: Upload a photo
+      requestBody:
+        required: true
+        content:
+          multipart/form-data:
+            schema: {type: object}
+components:
+  parameters:
+    PetId:
+      name: petId
+      in: path
+      required: true
+      description: The id of the pet
+      schema: {type: string}
+  schemas:
+    NewPet:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string, description: Name of the pet}
+        species: {type: string, enum: [cat, dog]}
+        tags:
+          type: array
+          items: {$ref: '#/components/schemas/Tag'}
+    Tag:
+      type: object
+      properties:
+        label: {type: string}
+`
+
+func TestImport(t *testing.T) {
+	result, err := Import([]byte(petstore), Options{Category: "pets", Owner: "team-pets"})
+	require.NoError(t, err)
+
+	names := make([]string, len(result.Tools))
+	for i, tool := range result.Tools {
+		names[i] = tool.Name
+	}
+	assert.Equal(t, []string{"listPets", "createPet", "get_pets_petId", "deletePet"}, names)
+	assert.Equal(t, []string{"POST /upload: skipped: request body has no JSON media type"}, result.Warnings)
+
+	list := result.Tools[0]
+	assert.Equal(t, "List all pets", list.Description)
+	assert.Equal(t, []string{"pets"}, list.Tags)
+	assert.Equal(t, "pets", list.Category)
+	assert.Equal(t, "team-pets", list.Owner)
+	assert.Equal(t, "integer", list.Parameters.Properties["limit"].Type)
+	assert.EqualValues(t, 20, list.Parameters.Properties["limit"].Default)
+	assert.Empty(t, list.Parameters.Required)
+
+	var listExecutor tools.HTTPExecutorSpec
+	require.NoError(t, json.Unmarshal(list.Executor, &listExecutor))
+	assert.Equal(t, tools.HTTPExecutorSpec{
+		Type:    "http",
+		Method:  "GET",
+		URL:     "https://petstore.example.com/v1/pets",
+		Query:   map[string]string{"limit": "{{.limit}}"},
+		Headers: map[string]string{"X-Request-Id": `{{index . "X-Request-Id"}}`},
+	}, listExecutor)
+
+	create := result.Tools[1]
+	assert.Equal(t, []string{"cat", "dog"}, create.Parameters.Properties["species"].Enum)
+	assert.Equal(t, []string{"name"}, create.Parameters.Required)
+	require.NotNil(t, create.Parameters.Properties["tags"].Items)
+	assert.Equal(t, "string", create.Parameters.Properties["tags"].Items.Properties["label"].Type)
+
+	get := result.Tools[2]
+	assert.Equal(t, "Info for a specific pet\n\nReturns the pet with the given ID.", get.Description)
+	assert.Equal(t, []string{"petId"}, get.Parameters.Required)
+	assert.Equal(t, "The id of the pet", get.Parameters.Properties["petId"].Description)
+}
+
+func TestImport_Filters(t *testing.T) {
+	tests := map[string]struct {
+		opts     Options
+		expected []string
+	}{
+		"include tag": {
+			opts:     Options{Include: []string{"admin"}},
+			expected: []string{"deletePet"},
+		},
+		"include path": {
+			opts:     Options{Include: []string{"/pets/*"}},
+			expected: []string{"get_pets_petId", "deletePet"},
+		},
+		"exclude tag and path": {
+			opts:     Options{Exclude: []string{"admin", "/upload"}},
+			expected: []string{"listPets", "createPet", "get_pets_petId"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			result, err := Import([]byte(petstore), tt.opts)
+			require.NoError(t, err)
+
+			names := make([]string, len(result.Tools))
+			for i, tool := range result.Tools {
+				names[i] = tool.Name
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestImport_Errors(t *testing.T) {
+	_, err := Import([]byte("swagger: '2.0'"), Options{})
+	assert.ErrorContains(t, err, "only OpenAPI 3 is supported")
+
+	_, err = Import([]byte("openapi: 3.0.0\nservers: [{url: /v1}]"), Options{})
+	assert.ErrorContains(t, err, "a base URL is required")
+}
+
+// TestImport_RoundTrip writes the generated tools to a definition file, loads it
+// and calls the generated handler against a test server
+func TestImport_RoundTrip(t *testing.T) {
+	var gotMethod, gotPath, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		fmt.Fprint(w, `{"id": "7"}`)
+	}))
+	defer server.Close()
+
+	result, err := Import([]byte(petstore), Options{BaseURL: server.URL + "/v1/", Include: []string{"/pets"}})
+	require.NoError(t, err)
+
+	data, err := tools.EncodeFile(result.Tools)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "petstore.yaml"), data, 0o644))
+	loaded, err := tools.LoadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	assert.Equal(t, result.Tools[1].ToolDefinition, loaded[1].Definition)
+
+	output, err := loaded[1].Handler(context.Background(), json.RawMessage(`{"name": "Rex"}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": "7"}, output)
+	assert.Equal(t, http.MethodPost, gotMethod)
+	assert.Equal(t, "/v1/pets", gotPath)
+	assert.JSONEq(t, `{"name": "Rex"}`, gotBody)
+}
@@ -0,0 +1,35 @@
+package rerank
+
+import (
+	"fmt"
+
+	"github.com/ddazal/marcopolo-go/internal/config"
+)
+
+// NewReranker creates a reranker based on configuration.
+// Returns nil without an error when reranking is disabled.
+func NewReranker(cfg config.RerankConfig) (Reranker, error) {
+	switch cfg.Provider {
+	case "", "none":
+		return nil, nil
+	case "lexical":
+		return NewLexicalReranker(), nil
+	case "cohere", "jina":
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = DefaultCohereBaseURL
+			if cfg.Provider == "jina" {
+				baseURL = DefaultJinaBaseURL
+			}
+		}
+		if cfg.Model == "" {
+			return nil, fmt.Errorf("model is required for %s reranker", cfg.Provider)
+		}
+		if cfg.ApiKey == "" && cfg.BaseURL == "" {
+			return nil, fmt.Errorf("api_key is required for %s reranker", cfg.Provider)
+		}
+		return NewHTTPReranker(baseURL, cfg.ApiKey, cfg.Model), nil
+	default:
+		return nil, fmt.Errorf("unsupported rerank provider: %s", cfg.Provider)
+	}
+}
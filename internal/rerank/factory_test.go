@@ -0,0 +1,77 @@
+package rerank
+
+import (
+	"testing"
+
+	"github.com/ddazal/marcopolo-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReranker(t *testing.T) {
+	tests := map[string]struct {
+		cfg          config.RerankConfig
+		expectNil    bool
+		expectType   Reranker
+		errorMsg     string
+		expectedBase string
+	}{
+		"disabled by default": {
+			cfg:       config.RerankConfig{},
+			expectNil: true,
+		},
+		"lexical": {
+			cfg:        config.RerankConfig{Provider: "lexical"},
+			expectType: &LexicalReranker{},
+		},
+		"cohere uses its default endpoint": {
+			cfg:          config.RerankConfig{Provider: "cohere", Model: "rerank-v3.5", ApiKey: "key"},
+			expectType:   &HTTPReranker{},
+			expectedBase: DefaultCohereBaseURL,
+		},
+		"jina uses its default endpoint": {
+			cfg:          config.RerankConfig{Provider: "jina", Model: "jina-reranker-v2-base-multilingual", ApiKey: "key"},
+			expectType:   &HTTPReranker{},
+			expectedBase: DefaultJinaBaseURL,
+		},
+		"local endpoint needs no api key": {
+			cfg:          config.RerankConfig{Provider: "jina", Model: "bge-reranker", BaseURL: "http://localhost:8080/v1"},
+			expectType:   &HTTPReranker{},
+			expectedBase: "http://localhost:8080/v1",
+		},
+		"hosted endpoint requires an api key": {
+			cfg:      config.RerankConfig{Provider: "cohere", Model: "rerank-v3.5"},
+			errorMsg: "api_key is required",
+		},
+		"requires a model": {
+			cfg:      config.RerankConfig{Provider: "cohere", ApiKey: "key"},
+			errorMsg: "model is required",
+		},
+		"unknown provider": {
+			cfg:      config.RerankConfig{Provider: "unknown"},
+			errorMsg: "unsupported rerank provider",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			reranker, err := NewReranker(tc.cfg)
+
+			if tc.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+
+			if tc.expectNil {
+				assert.Nil(t, reranker)
+				return
+			}
+			assert.IsType(t, tc.expectType, reranker)
+			if tc.expectedBase != "" {
+				assert.Equal(t, tc.expectedBase, reranker.(*HTTPReranker).baseURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Default endpoints of hosted rerank APIs. Both accept the same request shape.
+const (
+	DefaultCohereBaseURL = "https://api.cohere.com/v2"
+	DefaultJinaBaseURL   = "https://api.jina.ai/v1"
+)
+
+// HTTPReranker implements Reranker against a Cohere/Jina-style rerank API:
+// POST {baseURL}/rerank with a query and documents, answered with a relevance
+// score per document index.
+type HTTPReranker struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+}
+
+type httpRerankRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+type httpRerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+// httpRerankErrorResponse covers the error bodies of Cohere ("message") and Jina ("detail").
+type httpRerankErrorResponse struct {
+	Message string `json:"message"`
+	Detail  string `json:"detail"`
+}
+
+// NewHTTPReranker creates a rerank client for the API at baseURL.
+// apiKey is sent as a bearer token when set.
+func NewHTTPReranker(baseURL, apiKey, model string) *HTTPReranker {
+	return &HTTPReranker{
+		httpClient: http.DefaultClient,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+	}
+}
+
+// Rerank scores all documents in one request. Documents the API leaves out of
+// its results score 0.
+func (r *HTTPReranker) Rerank(ctx context.Context, query string, documents []string) ([]float64, error) {
+	scores := make([]float64, len(documents))
+	if len(documents) == 0 {
+		return scores, nil
+	}
+
+	payload, err := json.Marshal(httpRerankRequest{
+		Model:     r.model,
+		Query:     query,
+		Documents: documents,
+		TopN:      len(documents),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rerank request: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, r.baseURL+"/rerank", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rerank request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if r.apiKey != "" {
+		request.Header.Set("Authorization", "Bearer "+r.apiKey)
+	}
+
+	resp, err := r.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("rerank request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rerank response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp httpRerankErrorResponse
+		if json.Unmarshal(body, &errResp) == nil {
+			if msg := errResp.Message + errResp.Detail; msg != "" {
+				return nil, fmt.Errorf("rerank request failed: %s: %s", resp.Status, msg)
+			}
+		}
+		return nil, fmt.Errorf("rerank request failed: %s", resp.Status)
+	}
+
+	var rerankResp httpRerankResponse
+	if err := json.Unmarshal(body, &rerankResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rerank response: %w", err)
+	}
+
+	for _, result := range rerankResp.Results {
+		if result.Index < 0 || result.Index >= len(documents) {
+			return nil, fmt.Errorf("rerank response has index %d for %d documents", result.Index, len(documents))
+		}
+		scores[result.Index] = result.RelevanceScore
+	}
+
+	return scores, nil
+}
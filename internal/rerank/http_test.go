@@ -0,0 +1,98 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRerankServer creates a test HTTP server that mocks a Cohere/Jina-style rerank API
+func mockRerankServer(t *testing.T, response interface{}, statusCode int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify request
+		assert.Equal(t, "/v1/rerank", r.URL.Path)
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+
+		var req httpRerankRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "rerank-test", req.Model)
+		assert.NotEmpty(t, req.Query)
+		assert.Equal(t, len(req.Documents), req.TopN)
+
+		// Return mock response
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestHTTPReranker_Rerank(t *testing.T) {
+	documents := []string{"first", "second", "third"}
+
+	tests := map[string]struct {
+		mockResponse interface{}
+		statusCode   int
+		expected     []float64
+		errorMsg     string
+	}{
+		"maps scores back to document order": {
+			mockResponse: map[string]interface{}{
+				"results": []map[string]interface{}{
+					{"index": 2, "relevance_score": 0.9},
+					{"index": 0, "relevance_score": 0.4},
+				},
+			},
+			statusCode: http.StatusOK,
+			expected:   []float64{0.4, 0, 0.9},
+		},
+		"reports Cohere error messages": {
+			mockResponse: map[string]interface{}{"message": "invalid api token"},
+			statusCode:   http.StatusUnauthorized,
+			errorMsg:     "invalid api token",
+		},
+		"reports Jina error details": {
+			mockResponse: map[string]interface{}{"detail": "model not found"},
+			statusCode:   http.StatusBadRequest,
+			errorMsg:     "model not found",
+		},
+		"rejects out-of-range indexes": {
+			mockResponse: map[string]interface{}{
+				"results": []map[string]interface{}{{"index": 3, "relevance_score": 0.9}},
+			},
+			statusCode: http.StatusOK,
+			errorMsg:   "index 3",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := mockRerankServer(t, tc.mockResponse, tc.statusCode)
+			defer server.Close()
+
+			reranker := NewHTTPReranker(server.URL+"/v1/", "test-key", "rerank-test")
+			scores, err := reranker.Rerank(context.Background(), "find the third", documents)
+
+			if tc.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, scores)
+		})
+	}
+}
+
+func TestHTTPReranker_RerankWithoutDocuments(t *testing.T) {
+	reranker := NewHTTPReranker("http://127.0.0.1:0", "", "rerank-test")
+
+	scores, err := reranker.Rerank(context.Background(), "query", nil)
+	require.NoError(t, err)
+	assert.Empty(t, scores)
+}
@@ -0,0 +1,95 @@
+package rerank
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// minPrefixMatch is the shortest word that may match a longer word it prefixes,
+// so that "holiday" matches "holidays" but "to" does not match "tool".
+const minPrefixMatch = 4
+
+// stopWords are ignored in queries; they match almost every description.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "at": true, "be": true, "by": true,
+	"can": true, "do": true, "for": true, "from": true, "get": true, "how": true, "i": true,
+	"in": true, "is": true, "it": true, "me": true, "my": true, "of": true, "on": true,
+	"or": true, "the": true, "to": true, "what": true, "which": true, "with": true,
+}
+
+// LexicalReranker scores documents by the share of query words they contain.
+// It needs no network or model and is meant as a cheap tie-breaker for vector
+// retrieval, which often misses exact names and keywords.
+type LexicalReranker struct{}
+
+// NewLexicalReranker creates a new word-overlap reranker.
+func NewLexicalReranker() *LexicalReranker {
+	return &LexicalReranker{}
+}
+
+// Rerank returns, for each document, the fraction of distinct query words found in it.
+// A query word matches a document word that is equal to it, or that it shares a prefix
+// of at least minPrefixMatch letters with. Scores are in [0, 1].
+func (r *LexicalReranker) Rerank(_ context.Context, query string, documents []string) ([]float64, error) {
+	terms := queryTerms(query)
+
+	scores := make([]float64, len(documents))
+	if len(terms) == 0 {
+		return scores, nil
+	}
+
+	for i, document := range documents {
+		words := make(map[string]bool)
+		for _, word := range tokenize(document) {
+			words[word] = true
+		}
+
+		matched := 0
+		for _, term := range terms {
+			if containsTerm(words, term) {
+				matched++
+			}
+		}
+		scores[i] = float64(matched) / float64(len(terms))
+	}
+
+	return scores, nil
+}
+
+// queryTerms returns the distinct non-stop words of query, in order.
+func queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range tokenize(query) {
+		if stopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+func containsTerm(words map[string]bool, term string) bool {
+	if words[term] {
+		return true
+	}
+	for word := range words {
+		shorter, longer := word, term
+		if len(shorter) > len(longer) {
+			shorter, longer = longer, shorter
+		}
+		if len(shorter) >= minPrefixMatch && strings.HasPrefix(longer, shorter) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenize lowercases text and splits it into runs of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
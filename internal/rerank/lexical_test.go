@@ -0,0 +1,52 @@
+package rerank
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLexicalReranker_Rerank(t *testing.T) {
+	reranker := NewLexicalReranker()
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		query     string
+		documents []string
+		expected  []float64
+	}{
+		"scores the share of matched query words": {
+			query: "public holidays in Germany",
+			documents: []string{
+				"Tool: get_holidays\nDescription: Retrieve the list of all public holidays",
+				"Tool: get_weather\nDescription: Current weather for a city",
+			},
+			expected: []float64{2.0 / 3.0, 0},
+		},
+		"matches words sharing a prefix": {
+			query:     "holiday",
+			documents: []string{"Retrieve public holidays"},
+			expected:  []float64{1},
+		},
+		"ignores stop words": {
+			query:     "how do I get the weather",
+			documents: []string{"Current weather for a city"},
+			expected:  []float64{1},
+		},
+		"scores zero for a query of only stop words": {
+			query:     "what is the",
+			documents: []string{"the tool"},
+			expected:  []float64{0},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			scores, err := reranker.Rerank(ctx, tc.query, tc.documents)
+			require.NoError(t, err)
+			assert.InDeltaSlice(t, tc.expected, scores, 1e-9)
+		})
+	}
+}
@@ -0,0 +1,13 @@
+// Package rerank reorders search candidates by scoring them against the query.
+package rerank
+
+import "context"
+
+// Reranker scores candidate documents against a query. Retrieval finds candidates
+// cheaply; a reranker compares query and document directly and is slower but
+// usually orders them better.
+type Reranker interface {
+	// Rerank returns one relevance score per document, in input order.
+	// Higher scores mean more relevant.
+	Rerank(ctx context.Context, query string, documents []string) ([]float64, error)
+}
@@ -0,0 +1,155 @@
+// Package textdiff renders line-based unified diffs for human review.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change.
+const contextLines = 3
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	line string
+	// 1-based line numbers in a and b; the side a line is missing from keeps the previous number
+	aLine, bLine int
+}
+
+// Unified returns a unified diff of a and b labelled with fromName and toName.
+// Returns an empty string when the texts are equal.
+func Unified(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+	for _, hunk := range hunks(ops) {
+		writeHunk(&sb, hunk)
+	}
+	return sb.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes an edit script from the longest common subsequence of lines.
+func diffLines(a, b []string) []op {
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{kind: opEqual, line: a[i], aLine: i + 1, bLine: j + 1})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			// Prefer deletions so removed lines are listed before their replacements
+			ops = append(ops, op{kind: opDelete, line: a[i], aLine: i + 1, bLine: j})
+			i++
+		default:
+			ops = append(ops, op{kind: opInsert, line: b[j], aLine: i, bLine: j + 1})
+			j++
+		}
+	}
+	return ops
+}
+
+// hunks groups changes with up to contextLines of surrounding unchanged lines,
+// merging changes whose context would overlap.
+func hunks(ops []op) [][]op {
+	var result [][]op
+	start, end := -1, -1
+	for idx, o := range ops {
+		if o.kind == opEqual {
+			continue
+		}
+		lo := max(idx-contextLines, 0)
+		hi := min(idx+contextLines+1, len(ops))
+		if start >= 0 && lo <= end {
+			end = hi
+			continue
+		}
+		if start >= 0 {
+			result = append(result, ops[start:end])
+		}
+		start, end = lo, hi
+	}
+	if start >= 0 {
+		result = append(result, ops[start:end])
+	}
+	return result
+}
+
+func writeHunk(sb *strings.Builder, hunk []op) {
+	var aStart, bStart, aCount, bCount int
+	for _, o := range hunk {
+		switch o.kind {
+		case opEqual:
+			aCount++
+			bCount++
+		case opDelete:
+			aCount++
+		case opInsert:
+			bCount++
+		}
+	}
+
+	// Start lines come from the first op; for an empty side, diff convention is the line before
+	first := hunk[0]
+	aStart, bStart = first.aLine, first.bLine
+	if first.kind == opInsert {
+		aStart++
+	}
+	if first.kind == opDelete {
+		bStart++
+	}
+	if aCount == 0 {
+		aStart--
+	}
+	if bCount == 0 {
+		bStart--
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+	for _, o := range hunk {
+		fmt.Fprintf(sb, "%c%s\n", o.kind, o.line)
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
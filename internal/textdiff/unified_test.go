@@ -0,0 +1,52 @@
+package textdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified(t *testing.T) {
+	tests := map[string]struct {
+		a, b     string
+		expected string
+	}{
+		"equal texts": {
+			a:        "one\ntwo\n",
+			b:        "one\ntwo\n",
+			expected: "",
+		},
+		"replaced line": {
+			a: "Tool: get_holidays\nDescription: old\n",
+			b: "Tool: get_holidays\nDescription: new\n",
+			expected: "--- stored\n+++ registered\n" +
+				"@@ -1,2 +1,2 @@\n" +
+				" Tool: get_holidays\n" +
+				"-Description: old\n" +
+				"+Description: new\n",
+		},
+		"added to empty text": {
+			a:        "",
+			b:        "{}",
+			expected: "--- stored\n+++ registered\n@@ -0,0 +1 @@\n+{}\n",
+		},
+		"removed all lines": {
+			a:        "{}",
+			b:        "",
+			expected: "--- stored\n+++ registered\n@@ -1 +0,0 @@\n-{}\n",
+		},
+		"distant changes get separate hunks": {
+			a: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b: "1\nX\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			expected: "--- stored\n+++ registered\n" +
+				"@@ -1,5 +1,5 @@\n 1\n-2\n+X\n 3\n 4\n 5\n" +
+				"@@ -8,3 +8,4 @@\n 8\n 9\n 10\n+11\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Unified("stored", "registered", tc.a, tc.b))
+		})
+	}
+}
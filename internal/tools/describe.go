@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// DefaultDescriptionTemplate renders a tool's name, description and parameters,
+// including their types, descriptions, allowed values and defaults.
+const DefaultDescriptionTemplate = `Tool: {{.Name}}
+Description: {{.Description}}
+{{- with .Parameters}}
+Parameters:
+{{- range .}}
+- {{.Name}} ({{.Type}}{{if .Required}}, required{{end}}){{with .Description}}: {{.}}{{end}}
+{{- with .Enum}}; one of: {{join . ", "}}{{end}}
+{{- with .Default}}; default: {{.}}{{end}}
+{{- end}}
+{{- end}}`
+
+// DescriptionData is the data an embedding template is executed with.
+type DescriptionData struct {
+	Name        string
+	Description string
+	Parameters  []ParameterData // Sorted by name
+	Examples    []string
+	Tags        []string
+	Category    string
+	Owner       string
+}
+
+// ParameterData describes a single tool parameter to an embedding template.
+type ParameterData struct {
+	Name        string
+	Type        string
+	Description string
+	Enum        []string
+	Default     *string
+	Required    bool
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// Describer builds the text embedded for each tool from a text/template.
+type Describer struct {
+	tmpl    *template.Template
+	version string
+}
+
+// NewDescriber parses an embedding template. An empty text selects DefaultDescriptionTemplate.
+func NewDescriber(text string) (*Describer, error) {
+	if strings.TrimSpace(text) == "" {
+		text = DefaultDescriptionTemplate
+	}
+
+	tmpl, err := template.New("embedding").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid embedding template: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(text))
+	return &Describer{
+		tmpl:    tmpl,
+		version: hex.EncodeToString(sum[:6]),
+	}, nil
+}
+
+// Version identifies the template. It changes whenever the template text does,
+// so rows built by another template can be re-embedded.
+func (d *Describer) Version() string {
+	return d.version
+}
+
+// Describe renders the embedding text for a tool definition
+func (d *Describer) Describe(toolDef ToolDefinition) (ToolDescription, error) {
+	result := ToolDescription{
+		Examples:        toolDef.Examples,
+		TemplateVersion: d.version,
+	}
+
+	data := newDescriptionData(toolDef)
+
+	if len(toolDef.InputSchema) > 0 {
+		schema, err := parseSchema(toolDef.InputSchema)
+		if err != nil {
+			return result, err
+		}
+		data.Parameters = schemaParameters(schema)
+
+		// Re-marshalling sorts the keys, so the stored schema and its hash are stable
+		schemaJSON, err := json.Marshal(schema)
+		if err != nil {
+			return result, fmt.Errorf("failed to marshal input schema: %w", err)
+		}
+		schemaStr := string(schemaJSON)
+		result.InputSchema = &schemaStr
+	}
+	sort.Slice(data.Parameters, func(i, j int) bool {
+		return data.Parameters[i].Name < data.Parameters[j].Name
+	})
+
+	var buf bytes.Buffer
+	if err := d.tmpl.Execute(&buf, data); err != nil {
+		return result, fmt.Errorf("failed to render embedding template: %w", err)
+	}
+	result.Text = strings.TrimSpace(buf.String())
+
+	if toolDef.Parameters != nil {
+		paramsJSON, err := json.Marshal(toolDef.Parameters)
+		if err != nil {
+			return result, fmt.Errorf("failed to marshal parameters: %w", err)
+		}
+		paramsStr := string(paramsJSON)
+		result.InputSchema = &paramsStr
+	}
+
+	return result, nil
+}
+
+func newDescriptionData(toolDef ToolDefinition) DescriptionData {
+	data := DescriptionData{
+		Name:        toolDef.Name,
+		Description: toolDef.Description,
+		Examples:    toolDef.Examples,
+		Tags:        toolDef.Tags,
+		Category:    toolDef.Category,
+		Owner:       toolDef.Owner,
+	}
+
+	if toolDef.Parameters == nil {
+		return data
+	}
+
+	required := make(map[string]bool, len(toolDef.Parameters.Required))
+	for _, name := range toolDef.Parameters.Required {
+		required[name] = true
+	}
+
+	for name, prop := range toolDef.Parameters.Properties {
+		data.Parameters = append(data.Parameters, ParameterData{
+			Name:        name,
+			Type:        prop.Type,
+			Description: prop.Description,
+			Enum:        prop.Enum,
+			Default:     defaultText(prop.Default),
+			Required:    required[name],
+		})
+	}
+
+	return data
+}
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescriber_Describe(t *testing.T) {
+	def := ToolDefinition{
+		Name:        "get_weather",
+		Description: "Current weather for a city",
+		Parameters: &Parameters{
+			Properties: map[string]ParameterProperty{
+				"unit": {Type: "string", Description: "Temperature unit", Enum: []string{"celsius", "fahrenheit"}, Default: "celsius"},
+				"city": {Type: "string", Description: "City name"},
+			},
+			Required: []string{"city"},
+		},
+		Examples: []string{"Is it raining in Paris?"},
+		Tags:     []string{"weather"},
+	}
+
+	tests := map[string]struct {
+		template string
+		def      ToolDefinition
+		expected string
+	}{
+		"default template lists parameters by name": {
+			def: def,
+			expected: "Tool: get_weather\n" +
+				"Description: Current weather for a city\n" +
+				"Parameters:\n" +
+				"- city (string, required): City name\n" +
+				"- unit (string): Temperature unit; one of: celsius, fahrenheit; default: celsius",
+		},
+		"default template renders defaults that are not strings": {
+			def: ToolDefinition{Name: "list_issues", Description: "List issues", Parameters: &Parameters{
+				Properties: map[string]ParameterProperty{
+					"limit":  {Type: "integer", Default: float64(20)},
+					"labels": {Type: "array", Items: &ParameterProperty{Type: "string"}, Default: []any{"bug"}},
+				},
+			}},
+			expected: "Tool: list_issues\n" +
+				"Description: List issues\n" +
+				"Parameters:\n" +
+				"- labels (array); default: [\"bug\"]\n" +
+				"- limit (integer); default: 20",
+		},
+		"default template without parameters": {
+			def:      ToolDefinition{Name: "ping", Description: "Check the server"},
+			expected: "Tool: ping\nDescription: Check the server",
+		},
+		"custom template": {
+			template: "{{.Name}} [{{join .Tags \",\"}}]\n{{range .Examples}}{{.}}\n{{end}}",
+			def:      def,
+			expected: "get_weather [weather]\nIs it raining in Paris?",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			describer, err := NewDescriber(tt.template)
+			require.NoError(t, err)
+
+			description, err := describer.Describe(tt.def)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, description.Text)
+			assert.Equal(t, describer.Version(), description.TemplateVersion)
+		})
+	}
+}
+
+func TestNewDescriber(t *testing.T) {
+	defaultDescriber, err := NewDescriber("")
+	require.NoError(t, err)
+	explicit, err := NewDescriber(DefaultDescriptionTemplate)
+	require.NoError(t, err)
+	custom, err := NewDescriber("{{.Name}}")
+	require.NoError(t, err)
+
+	assert.Len(t, defaultDescriber.Version(), 12)
+	assert.Equal(t, defaultDescriber.Version(), explicit.Version())
+	assert.NotEqual(t, defaultDescriber.Version(), custom.Version())
+
+	_, err = NewDescriber("{{.Name")
+	assert.ErrorContains(t, err, "invalid embedding template")
+
+	unknownField, err := NewDescriber("{{.Unknown}}")
+	require.NoError(t, err)
+	_, err = unknownField.Describe(ToolDefinition{Name: "ping"})
+	assert.ErrorContains(t, err, "failed to render embedding template")
+}
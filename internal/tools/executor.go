@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ddazal/marcopolo-go/internal/mcp"
+)
+
+// executorFactory builds the handler of a file-defined tool from its executor spec.
+// The spec is the raw executor object, including its type.
+type executorFactory func(def ToolDefinition, spec json.RawMessage) (mcp.ToolHandler, error)
+
+// executorFactories maps executor types to their factories
+var executorFactories = map[string]executorFactory{
+	"go":   newGoExecutor,
+	"http": newHTTPExecutorFromSpec,
+}
+
+// executorTypes returns the supported executor types, sorted
+func executorTypes() []string {
+	types := make([]string, 0, len(executorFactories))
+	for kind := range executorFactories {
+		types = append(types, kind)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// newExecutor builds a handler from an executor spec, dispatching on its type
+func newExecutor(def ToolDefinition, spec json.RawMessage) (mcp.ToolHandler, error) {
+	if len(spec) == 0 {
+		return nil, fmt.Errorf("executor is required")
+	}
+
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(spec, &header); err != nil {
+		return nil, fmt.Errorf("invalid executor: %w", err)
+	}
+
+	factory, ok := executorFactories[header.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown executor type %q: must be one of %v", header.Type, executorTypes())
+	}
+	return factory(def, spec)
+}
+
+// goExecutorSpec runs a tool with a handler registered in Go, so a tool written in
+// Go can be published under another name, description or parameters.
+type goExecutorSpec struct {
+	Type    string `json:"type"`
+	Handler string `json:"handler"` // Name the Go handler was registered under
+}
+
+func newGoExecutor(_ ToolDefinition, spec json.RawMessage) (mcp.ToolHandler, error) {
+	var goSpec goExecutorSpec
+	if err := decodeStrict(spec, &goSpec); err != nil {
+		return nil, fmt.Errorf("invalid go executor: %w", err)
+	}
+	if goSpec.Handler == "" {
+		return nil, fmt.Errorf("go executor requires a handler")
+	}
+
+	handler, ok := mcp.GetExecutableTool(goSpec.Handler)
+	if !ok {
+		return nil, fmt.Errorf("go executor: no handler registered as %q", goSpec.Handler)
+	}
+	return handler, nil
+}
+
+// decodeStrict unmarshals JSON, rejecting fields that v does not declare
+func decodeStrict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
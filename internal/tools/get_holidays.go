@@ -50,6 +50,13 @@ func init() {
 			},
 			Required: []string{"year", "countryCode"},
 		},
+		Examples: []string{
+			"What are the public holidays in Germany this year?",
+			"Is next Monday a bank holiday in the UK?",
+			"Which days are offices closed in Spain in 2025?",
+		},
+		Tags:     []string{"calendar", "holidays"},
+		Category: "calendar",
 	}
 	Register(getHolidaysTool)
 
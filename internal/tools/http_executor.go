@@ -0,0 +1,610 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/ddazal/marcopolo-go/internal/jsonpath"
+	"github.com/ddazal/marcopolo-go/internal/mcp"
+)
+
+const (
+	defaultHTTPTimeout = 30 * time.Second
+
+	// maxHTTPResponseBytes caps how much of a response body is read
+	maxHTTPResponseBytes = 10 << 20
+	// maxHTTPErrorBody caps how much of an unmapped error response ends up in the error
+	maxHTTPErrorBody = 512
+)
+
+// HTTPExecutorSpec declares a tool that runs as a single HTTP request. The URL,
+// query, header, body and error message values are text/templates executed with
+// the tool's arguments, e.g. {{.countryCode}}. Arguments the caller omits are
+// set to their default in parameters or input_schema, or to "".
+type HTTPExecutorSpec struct {
+	Type   string `json:"type"`
+	Method string `json:"method,omitempty"` // Default: GET
+
+	// URL is the request URL. String arguments are path-escaped before they are
+	// substituted, and "." and ".." are rejected, so they cannot change the path structure.
+	URL string `json:"url"`
+
+	Query   map[string]string `json:"query,omitempty"`   // Parameters that render empty are omitted
+	Headers map[string]string `json:"headers,omitempty"` // Headers that render empty are omitted
+	Body    string            `json:"body,omitempty"`    // Sent as application/json unless a Content-Type header is set
+
+	// BodyArguments sends the named arguments as a JSON object body instead of
+	// Body. Only arguments the caller passed are included.
+	BodyArguments []string `json:"body_arguments,omitempty"`
+
+	Auth *HTTPAuthSpec `json:"auth,omitempty"`
+
+	// ResponsePath is a JSONPath selecting the result from a JSON response (default: the whole body)
+	ResponsePath string `json:"response_path,omitempty"`
+
+	// Errors maps a status code ("404") or class ("4xx") to an error message template.
+	// Other non-2xx responses fail with the status and the start of the body.
+	Errors map[string]string `json:"errors,omitempty"`
+
+	Timeout string `json:"timeout,omitempty"` // Go duration (default: 30s)
+}
+
+// HTTPAuthSpec authenticates requests with a secret. Secrets are references
+// resolved on every request: "env:NAME" reads an environment variable and
+// "file:/path" reads a file, such as a mounted secret.
+type HTTPAuthSpec struct {
+	Type string `json:"type"` // "bearer", "basic" or "header"
+
+	Token string `json:"token,omitempty"` // bearer: secret reference
+
+	Username string `json:"username,omitempty"` // basic: plain user name
+	Password string `json:"password,omitempty"` // basic: secret reference
+
+	Header string `json:"header,omitempty"` // header: header name, e.g. X-API-Key
+	Value  string `json:"value,omitempty"`  // header: secret reference
+}
+
+var httpTemplateFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// httpExecutor is a parsed HTTPExecutorSpec
+type httpExecutor struct {
+	def          ToolDefinition
+	defaults     map[string]any // Value of each declared parameter when omitted
+	method       string
+	url          *template.Template
+	urlArgs      map[string]bool // Arguments the URL template references
+	query        map[string]*template.Template
+	headers      map[string]*template.Template
+	body         *template.Template
+	bodyArgs     []string
+	auth         *HTTPAuthSpec
+	responsePath *jsonpath.Path
+	errors       map[string]*template.Template
+	client       *http.Client
+}
+
+func newHTTPExecutorFromSpec(def ToolDefinition, spec json.RawMessage) (mcp.ToolHandler, error) {
+	var httpSpec HTTPExecutorSpec
+	if err := decodeStrict(spec, &httpSpec); err != nil {
+		return nil, fmt.Errorf("invalid http executor: %w", err)
+	}
+	return NewHTTPExecutor(def, httpSpec)
+}
+
+// NewHTTPExecutor builds a handler that runs the tool as the HTTP request in spec
+func NewHTTPExecutor(def ToolDefinition, spec HTTPExecutorSpec) (mcp.ToolHandler, error) {
+	e := &httpExecutor{
+		def:      def,
+		method:   strings.ToUpper(spec.Method),
+		query:    make(map[string]*template.Template, len(spec.Query)),
+		headers:  make(map[string]*template.Template, len(spec.Headers)),
+		errors:   make(map[string]*template.Template, len(spec.Errors)),
+		auth:     spec.Auth,
+		bodyArgs: spec.BodyArguments,
+		client:   &http.Client{Timeout: defaultHTTPTimeout},
+		defaults: parameterDefaults(def),
+	}
+	if e.method == "" {
+		e.method = http.MethodGet
+	}
+
+	var err error
+	if spec.URL == "" {
+		return nil, fmt.Errorf("http executor requires a url")
+	}
+	if e.url, err = parseHTTPTemplate("url", spec.URL); err != nil {
+		return nil, err
+	}
+	for name, text := range spec.Query {
+		if e.query[name], err = parseHTTPTemplate("query "+name, text); err != nil {
+			return nil, err
+		}
+	}
+	for name, text := range spec.Headers {
+		if e.headers[name], err = parseHTTPTemplate("header "+name, text); err != nil {
+			return nil, err
+		}
+	}
+	if spec.Body != "" && len(spec.BodyArguments) > 0 {
+		return nil, fmt.Errorf("http executor cannot have both body and body_arguments")
+	}
+	if spec.Body != "" {
+		if e.body, err = parseHTTPTemplate("body", spec.Body); err != nil {
+			return nil, err
+		}
+	}
+	for status, text := range spec.Errors {
+		if !validStatusKey(status) {
+			return nil, fmt.Errorf("invalid error status %q: must be a status code like 404 or a class like 4xx", status)
+		}
+		if e.errors[strings.ToLower(status)], err = parseHTTPTemplate("error "+status, text); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := e.checkArguments(); err != nil {
+		return nil, err
+	}
+	e.urlArgs = make(map[string]bool)
+	for _, name := range templateArguments(e.url) {
+		e.urlArgs[name] = true
+	}
+
+	if spec.ResponsePath != "" {
+		if e.responsePath, err = jsonpath.Parse(spec.ResponsePath); err != nil {
+			return nil, err
+		}
+	}
+
+	if spec.Timeout != "" {
+		timeout, err := time.ParseDuration(spec.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q", spec.Timeout)
+		}
+		e.client.Timeout = timeout
+	}
+
+	if spec.Auth != nil {
+		if err := validateHTTPAuth(spec.Auth); err != nil {
+			return nil, err
+		}
+	}
+
+	return e.execute, nil
+}
+
+func parseHTTPTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(httpTemplateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// checkArguments verifies that the templates and body_arguments only use
+// declared parameters. Templates fail on missing keys, so an undeclared
+// argument would fail every call that omits it.
+func (e *httpExecutor) checkArguments() error {
+	templates := []*template.Template{e.url, e.body}
+	for _, tmpl := range e.query {
+		templates = append(templates, tmpl)
+	}
+	for _, tmpl := range e.headers {
+		templates = append(templates, tmpl)
+	}
+	for _, tmpl := range e.errors {
+		templates = append(templates, tmpl)
+	}
+	for _, tmpl := range templates {
+		if tmpl == nil {
+			continue
+		}
+		for _, name := range templateArguments(tmpl) {
+			if _, ok := e.defaults[name]; !ok {
+				return fmt.Errorf("%s template uses %q, which is not a declared parameter", tmpl.Name(), name)
+			}
+		}
+	}
+
+	for _, name := range e.bodyArgs {
+		if _, ok := e.defaults[name]; !ok {
+			return fmt.Errorf("body_arguments lists %q, which is not a declared parameter", name)
+		}
+	}
+	return nil
+}
+
+// parameterDefaults maps each declared parameter, from Parameters or from the
+// top-level properties of InputSchema, to the value it takes when omitted: its
+// default, or "".
+func parameterDefaults(def ToolDefinition) map[string]any {
+	defaults := make(map[string]any)
+	add := func(name string, value any) {
+		if value == nil {
+			value = ""
+		}
+		defaults[name] = value
+	}
+
+	if def.Parameters != nil {
+		for name, prop := range def.Parameters.Properties {
+			add(name, prop.Default)
+		}
+	}
+	if len(def.InputSchema) > 0 {
+		if schema, err := parseSchema(def.InputSchema); err == nil {
+			properties, _ := schema["properties"].(map[string]any)
+			for name, node := range properties {
+				propSchema, _ := node.(map[string]any)
+				add(name, propSchema["default"])
+			}
+		}
+	}
+	return defaults
+}
+
+// templateArguments lists the arguments a template reads: {{.name}}, {{$.name}}
+// and {{index . "name"}}. Fields read inside range and with, where dot is
+// something else, are not arguments.
+func templateArguments(tmpl *template.Template) []string {
+	var names []string
+	var walk func(node parse.Node, dotIsArgs bool)
+	walk = func(node parse.Node, dotIsArgs bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child, dotIsArgs)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, dotIsArgs)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd, dotIsArgs)
+			}
+		case *parse.CommandNode:
+			if len(n.Args) == 3 {
+				ident, isIdent := n.Args[0].(*parse.IdentifierNode)
+				_, isDot := n.Args[1].(*parse.DotNode)
+				key, isString := n.Args[2].(*parse.StringNode)
+				if isIdent && ident.Ident == "index" && isDot && isString && dotIsArgs {
+					names = append(names, key.Text)
+					return
+				}
+			}
+			for _, arg := range n.Args {
+				walk(arg, dotIsArgs)
+			}
+		case *parse.FieldNode:
+			if dotIsArgs {
+				names = append(names, n.Ident[0])
+			}
+		case *parse.VariableNode:
+			if n.Ident[0] == "$" && len(n.Ident) > 1 {
+				names = append(names, n.Ident[1])
+			}
+		case *parse.ChainNode:
+			walk(n.Node, dotIsArgs)
+		case *parse.IfNode:
+			walk(n.Pipe, dotIsArgs)
+			walk(n.List, dotIsArgs)
+			walk(n.ElseList, dotIsArgs)
+		case *parse.RangeNode:
+			walk(n.Pipe, dotIsArgs)
+			walk(n.List, false)
+			walk(n.ElseList, dotIsArgs)
+		case *parse.WithNode:
+			walk(n.Pipe, dotIsArgs)
+			walk(n.List, false)
+			walk(n.ElseList, dotIsArgs)
+		}
+	}
+	walk(tmpl.Tree.Root, true)
+	return names
+}
+
+func validStatusKey(key string) bool {
+	if len(key) != 3 {
+		return false
+	}
+	if strings.HasSuffix(strings.ToLower(key), "xx") {
+		return key[0] >= '1' && key[0] <= '5'
+	}
+	code, err := strconv.Atoi(key)
+	return err == nil && code >= 100 && code <= 599
+}
+
+func validateHTTPAuth(auth *HTTPAuthSpec) error {
+	switch auth.Type {
+	case "bearer":
+		return validateSecretRef("auth token", auth.Token)
+	case "basic":
+		if auth.Username == "" {
+			return fmt.Errorf("basic auth requires a username")
+		}
+		return validateSecretRef("auth password", auth.Password)
+	case "header":
+		if auth.Header == "" {
+			return fmt.Errorf("header auth requires a header name")
+		}
+		return validateSecretRef("auth value", auth.Value)
+	default:
+		return fmt.Errorf("unknown auth type %q: must be one of [basic bearer header]", auth.Type)
+	}
+}
+
+func validateSecretRef(name, ref string) error {
+	if !strings.HasPrefix(ref, "env:") && !strings.HasPrefix(ref, "file:") {
+		return fmt.Errorf("%s must be an env: or file: reference", name)
+	}
+	return nil
+}
+
+// resolveSecret reads the secret a reference points to
+func resolveSecret(ref string) (string, error) {
+	if name, ok := strings.CutPrefix(ref, "env:"); ok {
+		value := os.Getenv(name)
+		if value == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+	}
+	if path, ok := strings.CutPrefix(ref, "file:"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return "", fmt.Errorf("invalid secret reference")
+}
+
+// decodeArguments decodes the call arguments. Numbers are kept as json.Number
+// so they render exactly as sent.
+func decodeArguments(raw json.RawMessage) (map[string]any, error) {
+	args := make(map[string]any)
+	if len(raw) > 0 && string(raw) != "null" {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+	}
+	return args, nil
+}
+
+// withDefaults copies the arguments, filling in omitted parameters
+func (e *httpExecutor) withDefaults(provided map[string]any) map[string]any {
+	args := make(map[string]any, len(provided))
+	for name, value := range provided {
+		args[name] = value
+	}
+
+	for name, value := range e.defaults {
+		if _, ok := args[name]; !ok {
+			args[name] = value
+		}
+	}
+
+	return args
+}
+
+func renderHTTPTemplate(tmpl *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+func (e *httpExecutor) execute(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	provided, err := decodeArguments(arguments)
+	if err != nil {
+		return nil, err
+	}
+	args := e.withDefaults(provided)
+
+	request, err := e.newRequest(ctx, args, provided)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, e.statusError(resp, body, args)
+	}
+
+	return e.result(body)
+}
+
+func (e *httpExecutor) newRequest(ctx context.Context, args, provided map[string]any) (*http.Request, error) {
+	// Path-escape string arguments so they stay within their path segment.
+	// Escaping keeps "." and "..", which would still move up the path.
+	urlArgs := make(map[string]any, len(args))
+	for name, value := range args {
+		if s, ok := value.(string); ok {
+			if e.urlArgs[name] && (s == "." || s == "..") {
+				return nil, fmt.Errorf("invalid argument %s: %q is not allowed in the url", name, s)
+			}
+			value = url.PathEscape(s)
+		}
+		urlArgs[name] = value
+	}
+	rawURL, err := renderHTTPTemplate(e.url, urlArgs)
+	if err != nil {
+		return nil, err
+	}
+	requestURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %q: %w", rawURL, err)
+	}
+
+	query := requestURL.Query()
+	for name, tmpl := range e.query {
+		value, err := renderHTTPTemplate(tmpl, args)
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	requestURL.RawQuery = query.Encode()
+
+	var body io.Reader
+	if e.body != nil {
+		rendered, err := renderHTTPTemplate(e.body, args)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(rendered)
+	} else if len(e.bodyArgs) > 0 {
+		object := make(map[string]any, len(e.bodyArgs))
+		for _, name := range e.bodyArgs {
+			if value, ok := provided[name]; ok {
+				object[name] = value
+			}
+		}
+		data, err := json.Marshal(object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal body: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, e.method, requestURL.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	for name, tmpl := range e.headers {
+		value, err := renderHTTPTemplate(tmpl, args)
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			request.Header.Set(name, value)
+		}
+	}
+
+	if err := e.authenticate(request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+func (e *httpExecutor) authenticate(request *http.Request) error {
+	if e.auth == nil {
+		return nil
+	}
+
+	switch e.auth.Type {
+	case "bearer":
+		token, err := resolveSecret(e.auth.Token)
+		if err != nil {
+			return fmt.Errorf("auth token: %w", err)
+		}
+		request.Header.Set("Authorization", "Bearer "+token)
+	case "basic":
+		password, err := resolveSecret(e.auth.Password)
+		if err != nil {
+			return fmt.Errorf("auth password: %w", err)
+		}
+		request.SetBasicAuth(e.auth.Username, password)
+	case "header":
+		value, err := resolveSecret(e.auth.Value)
+		if err != nil {
+			return fmt.Errorf("auth value: %w", err)
+		}
+		request.Header.Set(e.auth.Header, value)
+	}
+	return nil
+}
+
+// statusError maps a non-2xx response to an error, preferring a message
+// configured for the exact status code over one for its class
+func (e *httpExecutor) statusError(resp *http.Response, body []byte, args map[string]any) error {
+	code := strconv.Itoa(resp.StatusCode)
+	tmpl, ok := e.errors[code]
+	if !ok {
+		tmpl, ok = e.errors[code[:1]+"xx"]
+	}
+	if ok {
+		message, err := renderHTTPTemplate(tmpl, args)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%s (HTTP %d)", message, resp.StatusCode)
+	}
+
+	detail := strings.TrimSpace(string(body))
+	if len(detail) > maxHTTPErrorBody {
+		detail = detail[:maxHTTPErrorBody] + "..."
+	}
+	if detail == "" {
+		return fmt.Errorf("request failed: %s", resp.Status)
+	}
+	return fmt.Errorf("request failed: %s: %s", resp.Status, detail)
+}
+
+// result decodes a JSON response and applies the response path. Responses
+// that are not JSON are returned as text when no path is configured.
+func (e *httpExecutor) result(body []byte) (interface{}, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		if e.responsePath != nil {
+			return nil, fmt.Errorf("empty response, expected JSON for %s", e.responsePath)
+		}
+		return nil, nil
+	}
+
+	var decoded any
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		if e.responsePath != nil {
+			return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+		return string(body), nil
+	}
+
+	if e.responsePath == nil {
+		return decoded, nil
+	}
+	return e.responsePath.Get(decoded)
+}
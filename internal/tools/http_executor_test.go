@@ -0,0 +1,324 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPExecutor_Get(t *testing.T) {
+	t.Setenv("HOLIDAYS_TOKEN", "secret-token")
+
+	var gotPath, gotQuery, gotAuth, gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		gotQuery = r.URL.RawQuery
+		gotAuth = r.Header.Get("Authorization")
+		gotHeader = r.Header.Get("X-Country")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data": {"holidays": [{"name": "New Year's Day"}, {"name": "Christmas Day"}]}}`)
+	}))
+	defer server.Close()
+
+	dir := writeToolFiles(t, map[string]string{
+		"holidays.yaml": `
+name: list_holidays
+description: List public holidays
+parameters:
+  properties:
+    year: {type: string, description: Year, default: "2025"}
+    countryCode: {type: string, description: Country code}
+    region: {type: string, description: Optional region}
+  required: [countryCode]
+executor:
+  type: http
+  url: "` + server.URL + `/holidays/{{.year}}/{{.countryCode}}"
+  query:
+    region: "{{.region}}"
+    lang: en
+  headers:
+    X-Country: "{{upper .countryCode}}"
+  auth:
+    type: bearer
+    token: env:HOLIDAYS_TOKEN
+  response_path: "$.data.holidays[*].name"
+`,
+	})
+
+	loaded, err := LoadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, loaded, 1)
+
+	result, err := loaded[0].Handler(context.Background(), json.RawMessage(`{"countryCode": "de/x"}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []any{"New Year's Day", "Christmas Day"}, result)
+	assert.Equal(t, "/holidays/2025/de%2Fx", gotPath)
+	assert.Equal(t, "lang=en", gotQuery)
+	assert.Equal(t, "Bearer secret-token", gotAuth)
+	assert.Equal(t, "DE/X", gotHeader)
+}
+
+func TestHTTPExecutor_PostBody(t *testing.T) {
+	var gotMethod, gotBody, gotContentType, gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotContentType = r.Header.Get("Content-Type")
+		gotKey = r.Header.Get("X-API-Key")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		fmt.Fprint(w, "created")
+	}))
+	defer server.Close()
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("file-key\n"), 0o600))
+
+	def := ToolDefinition{Name: "create_ticket", Parameters: &Parameters{Properties: map[string]ParameterProperty{
+		"title":    {Type: "string"},
+		"priority": {Type: "integer"},
+	}}}
+	handler, err := NewHTTPExecutor(def, HTTPExecutorSpec{
+		Method: "post",
+		URL:    server.URL + "/tickets",
+		Body:   `{"title": {{json .title}}, "priority": {{.priority}}}`,
+		Auth:   &HTTPAuthSpec{Type: "header", Header: "X-API-Key", Value: "file:" + keyFile},
+	})
+	require.NoError(t, err)
+
+	result, err := handler(context.Background(), json.RawMessage(`{"title": "Printer \"jammed\"", "priority": 12345678901234567890}`))
+	require.NoError(t, err)
+
+	// Non-JSON responses are returned as text
+	assert.Equal(t, "created", result)
+	assert.Equal(t, http.MethodPost, gotMethod)
+	assert.Equal(t, "application/json", gotContentType)
+	assert.Equal(t, "file-key", gotKey)
+	assert.Equal(t, `{"title": "Printer \"jammed\"", "priority": 12345678901234567890}`, gotBody)
+}
+
+func TestHTTPExecutor_Defaults(t *testing.T) {
+	var gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+	}))
+	defer server.Close()
+
+	def := ToolDefinition{Name: "search_issues", Parameters: &Parameters{Properties: map[string]ParameterProperty{
+		"labels": {Type: "array", Items: &ParameterProperty{Type: "string"}, Default: []any{"bug"}},
+		"limit":  {Type: "integer", Default: float64(20)},
+	}}}
+	handler, err := NewHTTPExecutor(def, HTTPExecutorSpec{
+		Method: "post",
+		URL:    server.URL + "/search",
+		Body:   `{"labels": {{json .labels}}, "limit": {{json .limit}}}`,
+	})
+	require.NoError(t, err)
+
+	_, err = handler(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	// Defaults keep their JSON type
+	assert.Equal(t, `{"labels": ["bug"], "limit": 20}`, gotBody)
+}
+
+func TestHTTPExecutor_InputSchemaDefaults(t *testing.T) {
+	var gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+	}))
+	defer server.Close()
+
+	def := ToolDefinition{Name: "list_issues", InputSchema: json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"repo": {"type": "string"},
+			"state": {"type": "string", "default": "open"},
+			"label": {"type": "string"}
+		},
+		"required": ["repo"]
+	}`)}
+	handler, err := NewHTTPExecutor(def, HTTPExecutorSpec{
+		URL:   server.URL + "/repos/{{.repo}}/issues",
+		Query: map[string]string{"state": "{{.state}}", "label": "{{.label}}"},
+	})
+	require.NoError(t, err)
+
+	_, err = handler(context.Background(), json.RawMessage(`{"repo": "marcopolo"}`))
+	require.NoError(t, err)
+
+	// Omitted arguments take their schema default, or "", which drops the query parameter
+	assert.Equal(t, "state=open", gotQuery)
+}
+
+func TestHTTPExecutor_StatusErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/404":
+			w.WriteHeader(http.StatusNotFound)
+		case "/409":
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `{"error": "already exists"}`)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	def := ToolDefinition{Name: "lookup", Parameters: &Parameters{Properties: map[string]ParameterProperty{
+		"status": {Type: "string"},
+	}}}
+	handler, err := NewHTTPExecutor(def, HTTPExecutorSpec{
+		URL: server.URL + "/{{.status}}",
+		Errors: map[string]string{
+			"404": "No record for {{.status}}",
+			"5xx": "Upstream unavailable, try again later",
+		},
+	})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		status   string
+		expected string
+	}{
+		"exact status":   {status: "404", expected: "No record for 404 (HTTP 404)"},
+		"status class":   {status: "502", expected: "Upstream unavailable, try again later (HTTP 502)"},
+		"unmapped error": {status: "409", expected: `request failed: 409 Conflict: {"error": "already exists"}`},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := handler(context.Background(), json.RawMessage(`{"status": "`+tt.status+`"}`))
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestHTTPExecutor_MissingSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request must not be sent without credentials")
+	}))
+	defer server.Close()
+
+	handler, err := NewHTTPExecutor(ToolDefinition{Name: "lookup"}, HTTPExecutorSpec{
+		URL:  server.URL,
+		Auth: &HTTPAuthSpec{Type: "bearer", Token: "env:MARCOPOLO_TEST_UNSET_TOKEN"},
+	})
+	require.NoError(t, err)
+
+	_, err = handler(context.Background(), nil)
+	assert.ErrorContains(t, err, "environment variable MARCOPOLO_TEST_UNSET_TOKEN is not set")
+}
+
+func TestNewHTTPExecutor_Errors(t *testing.T) {
+	tests := map[string]struct {
+		spec     HTTPExecutorSpec
+		expected string
+	}{
+		"missing url": {
+			spec:     HTTPExecutorSpec{},
+			expected: "requires a url",
+		},
+		"invalid template": {
+			spec:     HTTPExecutorSpec{URL: "https://example.com/{{.id"},
+			expected: "invalid url template",
+		},
+		"literal secret": {
+			spec:     HTTPExecutorSpec{URL: "https://example.com", Auth: &HTTPAuthSpec{Type: "bearer", Token: "abc"}},
+			expected: "auth token must be an env: or file: reference",
+		},
+		"unknown auth type": {
+			spec:     HTTPExecutorSpec{URL: "https://example.com", Auth: &HTTPAuthSpec{Type: "oauth"}},
+			expected: `unknown auth type "oauth"`,
+		},
+		"invalid status key": {
+			spec:     HTTPExecutorSpec{URL: "https://example.com", Errors: map[string]string{"40x": "x"}},
+			expected: `invalid error status "40x"`,
+		},
+		"invalid response path": {
+			spec:     HTTPExecutorSpec{URL: "https://example.com", ResponsePath: "data"},
+			expected: "must start with $",
+		},
+		"invalid timeout": {
+			spec:     HTTPExecutorSpec{URL: "https://example.com", Timeout: "soon"},
+			expected: `invalid timeout "soon"`,
+		},
+		"undeclared url argument": {
+			spec:     HTTPExecutorSpec{URL: "https://example.com/{{.id}}/{{.lang}}"},
+			expected: `url template uses "lang", which is not a declared parameter`,
+		},
+		"undeclared query argument": {
+			spec:     HTTPExecutorSpec{URL: "https://example.com", Query: map[string]string{"lang": "{{.lang}}"}},
+			expected: `query lang template uses "lang"`,
+		},
+		"undeclared indexed argument": {
+			spec:     HTTPExecutorSpec{URL: "https://example.com", Headers: map[string]string{"X-Id": `{{index . "X-Id"}}`}},
+			expected: `header X-Id template uses "X-Id"`,
+		},
+		"undeclared error argument": {
+			spec:     HTTPExecutorSpec{URL: "https://example.com", Errors: map[string]string{"404": "No {{$.kind}} {{.id}}"}},
+			expected: `error 404 template uses "kind"`,
+		},
+		"undeclared body argument": {
+			spec:     HTTPExecutorSpec{URL: "https://example.com", BodyArguments: []string{"id", "note"}},
+			expected: `body_arguments lists "note"`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			def := ToolDefinition{Name: "lookup", Parameters: &Parameters{Properties: map[string]ParameterProperty{
+				"id": {Type: "string"},
+			}}}
+			_, err := NewHTTPExecutor(def, tt.spec)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestTemplateArguments(t *testing.T) {
+	tmpl, err := parseHTTPTemplate("url", `/{{.year}}/{{lower .country}}{{if .region}}/{{index . "region-code"}}{{end}}{{range .ids}}/{{.value}}{{$.page}}{{end}}`)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"year", "country", "region", "region-code", "ids", "page"}, templateArguments(tmpl))
+}
+
+func TestHTTPExecutor_RejectsDotSegments(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	def := ToolDefinition{Name: "list_holidays", Parameters: &Parameters{Properties: map[string]ParameterProperty{
+		"year":        {Type: "string"},
+		"countryCode": {Type: "string"},
+		"lang":        {Type: "string"},
+	}}}
+	handler, err := NewHTTPExecutor(def, HTTPExecutorSpec{
+		URL:   server.URL + "/PublicHolidays/{{.year}}/{{.countryCode}}",
+		Query: map[string]string{"lang": "{{.lang}}"},
+	})
+	require.NoError(t, err)
+
+	for _, value := range []string{".", ".."} {
+		_, err := handler(context.Background(), json.RawMessage(`{"year": "`+value+`", "countryCode": "DE"}`))
+		assert.ErrorContains(t, err, "is not allowed in the url", value)
+	}
+	assert.Zero(t, requests, "no request may be sent with a dot segment")
+
+	// Dots are fine inside a segment and in arguments that are not part of the url
+	_, err = handler(context.Background(), json.RawMessage(`{"year": "2025..2026", "countryCode": "DE", "lang": ".."}`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ddazal/marcopolo-go/internal/mcp"
+	"go.yaml.in/yaml/v3"
+)
+
+// FileTool is a tool definition loaded from a file, with the handler built from
+// its executor spec
+type FileTool struct {
+	Definition ToolDefinition
+	Handler    mcp.ToolHandler
+	Path       string // File the tool was defined in
+}
+
+// FileDefinition is a tool as written in a definition file
+type FileDefinition struct {
+	ToolDefinition
+	Executor json.RawMessage `json:"executor"`
+}
+
+// LoadDir loads tool definitions from the *.yaml, *.yml and *.json files in dir,
+// in file name order. A file holds a single tool or a list of tools. Definitions
+// are validated, and a name defined twice is an error.
+func LoadDir(dir string) ([]FileTool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tools directory: %w", err)
+	}
+
+	var loaded []FileTool
+	definedIn := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		fileTools, err := loadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tool := range fileTools {
+			if other, exists := definedIn[tool.Definition.Name]; exists {
+				return nil, fmt.Errorf("%s: tool %q is already defined in %s", path, tool.Definition.Name, other)
+			}
+			definedIn[tool.Definition.Name] = path
+			loaded = append(loaded, tool)
+		}
+	}
+
+	return loaded, nil
+}
+
+// loadFile parses one definition file. JSON is valid YAML, so both formats go
+// through the YAML parser and are then decoded with the definitions' JSON tags.
+func loadFile(path string) ([]FileTool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var docs []any
+	switch v := doc.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		docs = v
+	default:
+		docs = []any{v}
+	}
+
+	fileTools := make([]FileTool, 0, len(docs))
+	for i, d := range docs {
+		tool, err := decodeFileTool(d)
+		if err != nil {
+			if len(docs) > 1 {
+				return nil, fmt.Errorf("%s: tool %d: %w", path, i+1, err)
+			}
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		tool.Path = path
+		fileTools = append(fileTools, tool)
+	}
+
+	return fileTools, nil
+}
+
+func decodeFileTool(doc any) (FileTool, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return FileTool{}, fmt.Errorf("invalid tool definition: %w", err)
+	}
+
+	var entry FileDefinition
+	if err := decodeStrict(data, &entry); err != nil {
+		return FileTool{}, fmt.Errorf("invalid tool definition: %w", err)
+	}
+
+	def := entry.ToolDefinition
+	if err := def.Validate(); err != nil {
+		return FileTool{}, fmt.Errorf("invalid tool %q: %w", def.Name, err)
+	}
+
+	handler, err := newExecutor(def, entry.Executor)
+	if err != nil {
+		return FileTool{}, fmt.Errorf("tool %q: %w", def.Name, err)
+	}
+
+	return FileTool{Definition: def, Handler: handler}, nil
+}
+
+// EncodeFile renders tool definitions as a YAML definition file that LoadDir reads.
+// Fields keep their declaration order.
+func EncodeFile(defs []FileDefinition) ([]byte, error) {
+	data, err := json.Marshal(defs)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is YAML, and parsing it into a node keeps the key order. Clearing the
+	// styles turns the JSON flow syntax into block YAML.
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	clearYAMLStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeToolFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := writeToolFiles(t, map[string]string{
+		"holidays.yaml": `
+name: list_bank_holidays
+description: List the bank holidays of a country
+parameters:
+  properties:
+    year:
+      type: string
+      description: Year to list
+    countryCode:
+      type: string
+      description: ISO 3166-1 alpha-2 country code
+      enum: [DE, GB]
+  required: [year, countryCode]
+tags: [calendar]
+category: calendar
+executor:
+  type: go
+  handler: get_holidays
+`,
+		"more.json": `[
+  {"name": "holidays_a", "description": "A", "executor": {"type": "go", "handler": "get_holidays"}},
+  {"name": "holidays_b", "description": "B", "executor": {"type": "go", "handler": "get_holidays"}}
+]`,
+		"README.md": "not a tool",
+	})
+
+	loaded, err := LoadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, loaded, 3)
+
+	// Files are read in name order
+	assert.Equal(t, "list_bank_holidays", loaded[0].Definition.Name)
+	assert.Equal(t, filepath.Join(dir, "holidays.yaml"), loaded[0].Path)
+	assert.Equal(t, []string{"DE", "GB"}, loaded[0].Definition.Parameters.Properties["countryCode"].Enum)
+	assert.Equal(t, []string{"year", "countryCode"}, loaded[0].Definition.Parameters.Required)
+	assert.Equal(t, []string{"calendar"}, loaded[0].Definition.Tags)
+	assert.NotNil(t, loaded[0].Handler)
+	assert.Equal(t, "holidays_a", loaded[1].Definition.Name)
+	assert.Equal(t, "holidays_b", loaded[2].Definition.Name)
+}
+
+func TestLoadDir_NestedParameters(t *testing.T) {
+	dir := writeToolFiles(t, map[string]string{
+		"issues.yaml": `
+name: search_issues
+description: Search issues
+parameters:
+  properties:
+    labels:
+      type: array
+      description: Labels the issues must have
+      items: {type: string}
+      default: [bug]
+    filter:
+      type: object
+      properties:
+        state: {type: string, enum: [open, closed], description: Issue state}
+      required: [state]
+    limit: {type: integer, description: Page size, default: 20}
+executor:
+  type: go
+  handler: get_holidays
+`,
+	})
+
+	loaded, err := LoadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, loaded, 1)
+
+	props := loaded[0].Definition.Parameters.Properties
+	assert.Equal(t, &ParameterProperty{Type: "string"}, props["labels"].Items)
+	assert.Equal(t, []any{"bug"}, props["labels"].Default)
+	assert.Equal(t, []string{"open", "closed"}, props["filter"].Properties["state"].Enum)
+	assert.Equal(t, []string{"state"}, props["filter"].Required)
+	assert.Equal(t, float64(20), props["limit"].Default)
+}
+
+func TestLoadDir_Errors(t *testing.T) {
+	tests := map[string]struct {
+		files    map[string]string
+		expected string
+	}{
+		"unknown field": {
+			files:    map[string]string{"a.yaml": "name: a\ndescription: A\nsummary: A\nexecutor: {type: go, handler: get_holidays}"},
+			expected: `unknown field "summary"`,
+		},
+		"missing description": {
+			files:    map[string]string{"a.yaml": "name: a\nexecutor: {type: go, handler: get_holidays}"},
+			expected: "description is required",
+		},
+		"required parameter not in properties": {
+			files:    map[string]string{"a.yaml": "name: a\ndescription: A\nparameters: {required: [year]}\nexecutor: {type: go, handler: get_holidays}"},
+			expected: `required key "year" not found in properties`,
+		},
+		"missing executor": {
+			files:    map[string]string{"a.yaml": "name: a\ndescription: A"},
+			expected: "executor is required",
+		},
+		"unknown executor type": {
+			files:    map[string]string{"a.yaml": "name: a\ndescription: A\nexecutor: {type: shell}"},
+			expected: `unknown executor type "shell"`,
+		},
+		"unknown go handler": {
+			files:    map[string]string{"a.yaml": "name: a\ndescription: A\nexecutor: {type: go, handler: missing}"},
+			expected: `no handler registered as "missing"`,
+		},
+		"duplicate name": {
+			files: map[string]string{
+				"a.yaml": "name: a\ndescription: A\nexecutor: {type: go, handler: get_holidays}",
+				"b.json": `{"name": "a", "description": "A", "executor": {"type": "go", "handler": "get_holidays"}}`,
+			},
+			expected: `tool "a" is already defined in`,
+		},
+		"invalid yaml": {
+			files:    map[string]string{"a.yaml": "name: [a"},
+			expected: "failed to parse",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadDir(writeToolFiles(t, tt.files))
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
@@ -15,3 +15,13 @@ func Register(tool ToolDefinition) {
 func GetAllTools() []ToolDefinition {
 	return registry
 }
+
+// GetTool returns the registered tool definition with the given name
+func GetTool(name string) (ToolDefinition, bool) {
+	for _, tool := range registry {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return ToolDefinition{}, false
+}
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PropertyFromSchema describes a JSON Schema as a parameter property. The type
+// is inferred when the schema omits it, enums are rendered as strings, and the
+// items and properties of arrays and objects are converted as well.
+func PropertyFromSchema(schema map[string]any) ParameterProperty {
+	prop := ParameterProperty{Type: schemaType(schema)}
+	if description, ok := schema["description"].(string); ok {
+		prop.Description = strings.TrimSpace(description)
+	}
+	if values, ok := schema["enum"].([]any); ok {
+		for _, value := range values {
+			if value != nil {
+				prop.Enum = append(prop.Enum, fmt.Sprint(value))
+			}
+		}
+	}
+	prop.Default = schema["default"]
+	if items, ok := schema["items"].(map[string]any); ok {
+		itemProp := PropertyFromSchema(items)
+		prop.Items = &itemProp
+	}
+	if properties, ok := schema["properties"].(map[string]any); ok {
+		prop.Properties = make(map[string]ParameterProperty, len(properties))
+		for name, node := range properties {
+			propSchema, _ := node.(map[string]any)
+			prop.Properties[name] = PropertyFromSchema(propSchema)
+		}
+	}
+	if required, ok := schema["required"].([]any); ok {
+		for _, item := range required {
+			if name, ok := item.(string); ok {
+				prop.Required = append(prop.Required, name)
+			}
+		}
+	}
+	return prop
+}
+
+// defaultText renders a default value for embedding templates: strings as they
+// are, arrays and objects as JSON and other values with fmt. nil stays nil.
+func defaultText(value any) *string {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		text = v
+	case map[string]any, []any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		text = string(data)
+	default:
+		text = fmt.Sprint(v)
+	}
+	return &text
+}
+
+// schemaType returns a schema's type, inferring it when the type is omitted
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		// Type lists such as ["string", "null"]
+		for _, item := range t {
+			if s, ok := item.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	if _, ok := schema["items"]; ok {
+		return "array"
+	}
+	return "string"
+}
+
+// parseSchema decodes a JSON Schema that must be an object
+func parseSchema(raw json.RawMessage) (map[string]any, error) {
+	var schema map[string]any
+	if err := json.Unmarshal(raw, &schema); err != nil || schema == nil {
+		return nil, fmt.Errorf("input_schema must be a JSON object")
+	}
+	return schema, nil
+}
+
+// schemaParameters lists the top-level properties of an object schema
+func schemaParameters(schema map[string]any) []ParameterData {
+	properties, _ := schema["properties"].(map[string]any)
+
+	required := make(map[string]bool)
+	if list, ok := schema["required"].([]any); ok {
+		for _, item := range list {
+			if name, ok := item.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	params := make([]ParameterData, 0, len(properties))
+	for name, node := range properties {
+		propSchema, _ := node.(map[string]any)
+		prop := PropertyFromSchema(propSchema)
+		params = append(params, ParameterData{
+			Name:        name,
+			Type:        prop.Type,
+			Description: prop.Description,
+			Enum:        prop.Enum,
+			Default:     defaultText(prop.Default),
+			Required:    required[name],
+		})
+	}
+	return params
+}
@@ -1,17 +1,25 @@
 package tools
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strings"
 )
 
-// ParameterProperty represents a single parameter property
+// ParameterProperty represents a single parameter property. Arrays describe
+// their elements with Items and objects their fields with Properties and Required.
+// Enum values are strings; validation also accepts the value a string spells, e.g. 2 for "2".
 type ParameterProperty struct {
 	Type        string   `json:"type"`
 	Description string   `json:"description"`
 	Enum        []string `json:"enum,omitzero"`
-	Default     *string  `json:"default,omitempty"`
+	Default     any      `json:"default,omitempty"` // Any JSON value
+
+	Items      *ParameterProperty           `json:"items,omitempty"`
+	Properties map[string]ParameterProperty `json:"properties,omitempty"`
+	Required   []string                     `json:"required,omitempty"`
 }
 
 // Parameter represents the parameters schema for a tool
@@ -35,10 +43,50 @@ type ToolDefinition struct {
 	Name        string      `json:"name"`
 	Description string      `json:"description"`
 	Parameters  *Parameters `json:"parameters,omitempty"`
+
+	// InputSchema is a JSON Schema for the arguments, used instead of Parameters
+	// when they cannot express it, such as for tools of upstream MCP servers
+	InputSchema json.RawMessage `json:"input_schema,omitempty"`
+
+	// Source names the upstream MCP server the tool is proxied to ("" = local)
+	Source string `json:"-"`
+
+	// Examples are user queries the tool answers. Each is embedded separately,
+	// so searches phrased like an example find the tool.
+	Examples []string `json:"examples,omitempty"`
+
+	// Metadata used to scope searches; it is not part of the embedded text
+	Tags     []string `json:"tags,omitempty"`
+	Category string   `json:"category,omitempty"`
+	Owner    string   `json:"owner,omitempty"`
 }
 
 // Validate validates the tool definition
 func (t *ToolDefinition) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if strings.TrimSpace(t.Description) == "" {
+		return fmt.Errorf("description is required")
+	}
+	for _, example := range t.Examples {
+		if strings.TrimSpace(example) == "" {
+			return fmt.Errorf("examples must not be empty")
+		}
+	}
+	for _, tag := range t.Tags {
+		if strings.TrimSpace(tag) == "" {
+			return fmt.Errorf("tags must not be empty")
+		}
+	}
+	if len(t.InputSchema) > 0 {
+		if t.Parameters != nil {
+			return fmt.Errorf("parameters and input_schema cannot both be set")
+		}
+		if _, err := parseSchema(t.InputSchema); err != nil {
+			return err
+		}
+	}
 	if t.Parameters != nil {
 		return t.Parameters.Validate()
 	}
@@ -46,29 +94,41 @@ func (t *ToolDefinition) Validate() error {
 }
 
 type ToolDescription struct {
-	Text        string  `json:"text"`
-	InputSchema *string `json:"input_schema,omitempty"`
-}
+	Text        string   `json:"text"`
+	InputSchema *string  `json:"input_schema,omitempty"`
+	Examples    []string `json:"examples,omitempty"`
 
-// DescribeTool generates a description for a tool definition
-func DescribeTool(toolDef ToolDefinition) (ToolDescription, error) {
-	text := strings.Join([]string{
-		fmt.Sprintf("Tool: %s", toolDef.Name),
-		fmt.Sprintf("Description: %s", toolDef.Description),
-	}, "\n")
+	// TemplateVersion identifies the embedding template that rendered Text
+	TemplateVersion string `json:"template_version"`
+}
 
-	result := ToolDescription{
-		Text: text,
+// ContentHash returns a SHA-256 fingerprint of the description text, input schema
+// and examples. Two descriptions with the same hash produce the same embeddings.
+func (d ToolDescription) ContentHash() string {
+	h := sha256.New()
+	h.Write([]byte(d.Text))
+	h.Write([]byte{0})
+	if d.InputSchema != nil {
+		h.Write([]byte(*d.InputSchema))
+	}
+	// Tools without examples keep the hash they had before examples existed
+	for _, example := range d.Examples {
+		h.Write([]byte{0})
+		h.Write([]byte(example))
 	}
+	return hex.EncodeToString(h.Sum(nil))
+}
 
-	if toolDef.Parameters != nil {
-		paramsJSON, err := json.Marshal(toolDef.Parameters)
-		if err != nil {
-			return result, fmt.Errorf("failed to marshal parameters: %w", err)
-		}
-		paramsStr := string(paramsJSON)
-		result.InputSchema = &paramsStr
+// defaultDescriber renders DefaultDescriptionTemplate, which always parses
+var defaultDescriber = func() *Describer {
+	d, err := NewDescriber(DefaultDescriptionTemplate)
+	if err != nil {
+		panic(err)
 	}
+	return d
+}()
 
-	return result, nil
+// DescribeTool generates a description for a tool definition using DefaultDescriptionTemplate
+func DescribeTool(toolDef ToolDefinition) (ToolDescription, error) {
+	return defaultDescriber.Describe(toolDef)
 }
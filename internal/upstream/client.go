@@ -0,0 +1,255 @@
+// Package upstream connects to other MCP servers so their tools can be indexed
+// and searched next to local tools, and proxies execute_tool calls to them.
+package upstream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ddazal/marcopolo-go/internal/config"
+	"github.com/ddazal/marcopolo-go/internal/tools"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+)
+
+// DefaultTimeout bounds each request to an upstream server, including connecting
+const DefaultTimeout = 30 * time.Second
+
+// Client is a session with one upstream MCP server. It connects on first use,
+// and a session that fails at the transport level is dropped so the next
+// request reconnects.
+type Client struct {
+	cfg config.UpstreamConfig
+
+	mu      sync.Mutex
+	session *client.Client // nil until connected
+}
+
+// NewClient creates a client for an upstream server without connecting to it
+func NewClient(cfg config.UpstreamConfig) *Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	return &Client{cfg: cfg}
+}
+
+// Name returns the upstream's name, which namespaces its tools
+func (c *Client) Name() string {
+	return c.cfg.Name
+}
+
+// connected returns the current session, connecting if there is none
+func (c *Client) connected(ctx context.Context) (*client.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session != nil {
+		return c.session, nil
+	}
+
+	session, err := c.connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to upstream %q: %w", c.cfg.Name, err)
+	}
+	c.session = session
+	return session, nil
+}
+
+func (c *Client) connect(ctx context.Context) (*client.Client, error) {
+	var session *client.Client
+	if c.cfg.Command != "" {
+		var err error
+		session, err = client.NewStdioMCPClient(c.cfg.Command, c.cfg.Env, c.cfg.Args...)
+		if err != nil {
+			return nil, err
+		}
+		// The server's stderr must be drained, or it blocks once the pipe is full
+		if stderr, ok := client.GetStderr(session); ok {
+			go logStderr(c.cfg.Name, stderr)
+		}
+	} else {
+		var err error
+		session, err = client.NewStreamableHttpClient(c.cfg.URL, transport.WithHTTPHeaders(c.cfg.Headers))
+		if err != nil {
+			return nil, err
+		}
+		if err := session.Start(ctx); err != nil {
+			session.Close()
+			return nil, err
+		}
+	}
+
+	request := mcpgo.InitializeRequest{}
+	request.Params.ProtocolVersion = mcpgo.LATEST_PROTOCOL_VERSION
+	request.Params.ClientInfo = mcpgo.Implementation{Name: "marcopolo-go", Version: "1.0.0"}
+	if _, err := session.Initialize(ctx, request); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("initialize: %w", err)
+	}
+
+	return session, nil
+}
+
+func logStderr(name string, stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		log.Printf("upstream %s: %s", name, scanner.Text())
+	}
+}
+
+// drop closes a session that failed, unless another request already replaced it
+func (c *Client) drop(session *client.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == session {
+		c.session.Close()
+		c.session = nil
+	}
+}
+
+// Close ends the current session, if any
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == nil {
+		return nil
+	}
+	err := c.session.Close()
+	c.session = nil
+	return err
+}
+
+// isTransportError reports whether err came from the connection rather than
+// from the server answering the request
+func isTransportError(err error) bool {
+	var transportErr *transport.Error
+	return errors.As(err, &transportErr)
+}
+
+// ListTools returns every tool of the upstream server. Listing is idempotent,
+// so a request that fails at the transport level is retried once on a new session.
+func (c *Client) ListTools(ctx context.Context) ([]mcpgo.Tool, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	var lastErr error
+	for range 2 {
+		session, err := c.connected(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := session.ListTools(ctx, mcpgo.ListToolsRequest{})
+		if err == nil {
+			return result.Tools, nil
+		}
+		lastErr = err
+		if !isTransportError(err) {
+			break
+		}
+		c.drop(session)
+	}
+
+	return nil, fmt.Errorf("upstream %q: list tools: %w", c.cfg.Name, lastErr)
+}
+
+// CallTool calls a tool of the upstream server by its upstream name. A tool may
+// have side effects, so a call is only retried when the server reports that it
+// no longer knows the session, which means the call was not processed.
+func (c *Client) CallTool(ctx context.Context, name string, arguments json.RawMessage) (*mcpgo.CallToolResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	request := mcpgo.CallToolRequest{}
+	request.Params.Name = name
+	if len(arguments) > 0 {
+		request.Params.Arguments = arguments
+	}
+
+	var lastErr error
+	for range 2 {
+		session, err := c.connected(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := session.CallTool(ctx, request)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+		if !isTransportError(err) {
+			break
+		}
+		c.drop(session)
+		if !errors.Is(err, transport.ErrSessionTerminated) {
+			break
+		}
+	}
+
+	return nil, fmt.Errorf("upstream %q: call %s: %w", c.cfg.Name, name, lastErr)
+}
+
+// ToolDefinitions lists the upstream's tools as namespaced tool definitions
+func (c *Client) ToolDefinitions(ctx context.Context) ([]tools.ToolDefinition, error) {
+	upstreamTools, err := c.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defs := make([]tools.ToolDefinition, 0, len(upstreamTools))
+	for _, tool := range upstreamTools {
+		def, err := c.toolDefinition(tool)
+		if err != nil {
+			return nil, fmt.Errorf("upstream %q: tool %q: %w", c.cfg.Name, tool.Name, err)
+		}
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+func (c *Client) toolDefinition(tool mcpgo.Tool) (tools.ToolDefinition, error) {
+	description := strings.TrimSpace(tool.Description)
+	if description == "" {
+		description = strings.TrimSpace(tool.Annotations.Title)
+	}
+	if description == "" {
+		description = fmt.Sprintf("Tool %s of %s", tool.Name, c.cfg.Name)
+	}
+
+	// mcp.Tool marshals whichever of its schema representations is set
+	toolJSON, err := json.Marshal(tool)
+	if err != nil {
+		return tools.ToolDefinition{}, err
+	}
+	var fields struct {
+		InputSchema json.RawMessage `json:"inputSchema"`
+	}
+	if err := json.Unmarshal(toolJSON, &fields); err != nil {
+		return tools.ToolDefinition{}, err
+	}
+
+	def := tools.ToolDefinition{
+		Name:        ToolName(c.cfg.Name, tool.Name),
+		Description: description,
+		InputSchema: fields.InputSchema,
+		Tags:        c.cfg.Tags,
+		Category:    c.cfg.Category,
+		Source:      c.cfg.Name,
+	}
+	if err := def.Validate(); err != nil {
+		return tools.ToolDefinition{}, err
+	}
+	return def, nil
+}
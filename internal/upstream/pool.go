@@ -0,0 +1,122 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ddazal/marcopolo-go/internal/config"
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+)
+
+// nameSeparator joins an upstream's name and its tool's name
+const nameSeparator = "."
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ToolName namespaces a tool of an upstream server
+func ToolName(upstream, tool string) string {
+	return upstream + nameSeparator + tool
+}
+
+// SplitToolName splits a namespaced tool name into the upstream and tool names
+func SplitToolName(name string) (upstream, tool string, ok bool) {
+	return strings.Cut(name, nameSeparator)
+}
+
+// Pool holds a client per configured upstream server.
+type Pool struct {
+	clients []*Client
+	byName  map[string]*Client
+}
+
+// NewPool validates the upstream configs and creates their clients. Nothing
+// connects until a client is used.
+func NewPool(cfgs []config.UpstreamConfig) (*Pool, error) {
+	pool := &Pool{byName: make(map[string]*Client, len(cfgs))}
+	for i, cfg := range cfgs {
+		if !validName.MatchString(cfg.Name) {
+			return nil, fmt.Errorf("upstreams[%d]: name %q must be letters, digits, - or _", i, cfg.Name)
+		}
+		if _, exists := pool.byName[cfg.Name]; exists {
+			return nil, fmt.Errorf("upstreams[%d]: duplicate name %q", i, cfg.Name)
+		}
+		if (cfg.Command == "") == (cfg.URL == "") {
+			return nil, fmt.Errorf("upstream %q: set exactly one of command and url", cfg.Name)
+		}
+
+		c := NewClient(cfg)
+		pool.clients = append(pool.clients, c)
+		pool.byName[cfg.Name] = c
+	}
+	return pool, nil
+}
+
+// Clients returns the clients in config order
+func (p *Pool) Clients() []*Client {
+	return p.clients
+}
+
+// CallTool proxies a call to a namespaced tool. handled is false when the name
+// does not belong to a configured upstream.
+func (p *Pool) CallTool(ctx context.Context, name string, arguments json.RawMessage) (interface{}, bool, error) {
+	upstreamName, toolName, ok := SplitToolName(name)
+	if !ok {
+		return nil, false, nil
+	}
+	c, ok := p.byName[upstreamName]
+	if !ok {
+		return nil, false, nil
+	}
+
+	result, err := c.CallTool(ctx, toolName, arguments)
+	if err != nil {
+		return nil, true, err
+	}
+	output, err := toolResult(result)
+	return output, true, err
+}
+
+// toolResult converts an upstream result to an execute_tool result: structured
+// content if there is any, text if all content is text, else the content list.
+// A result flagged as an error becomes an error with its text.
+func toolResult(result *mcpgo.CallToolResult) (interface{}, error) {
+	texts := make([]string, 0, len(result.Content))
+	allText := true
+	for _, content := range result.Content {
+		if text, ok := mcpgo.AsTextContent(content); ok {
+			texts = append(texts, text.Text)
+		} else {
+			allText = false
+		}
+	}
+	text := strings.Join(texts, "\n")
+
+	if result.IsError {
+		if text == "" {
+			text = "upstream tool failed"
+		}
+		return nil, errors.New(text)
+	}
+	if result.StructuredContent != nil {
+		return result.StructuredContent, nil
+	}
+	if allText {
+		return text, nil
+	}
+	return result.Content, nil
+}
+
+// Close ends every session
+func (p *Pool) Close() error {
+	var errs []error
+	for _, c := range p.clients {
+		if err := c.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("upstream %q: %w", c.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,217 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ddazal/marcopolo-go/internal/config"
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testUpstream serves an MCP server with an echo tool and a failing tool.
+// Setting expire makes the next request on an existing session fail with 404,
+// as if the server had restarted.
+type testUpstream struct {
+	url         string
+	expire      atomic.Bool
+	initializes atomic.Int32
+	calls       atomic.Int32
+}
+
+func newTestUpstream(t *testing.T) *testUpstream {
+	t.Helper()
+
+	upstream := &testUpstream{}
+
+	mcpServer := server.NewMCPServer("test-upstream", "1.0.0")
+	mcpServer.AddTool(
+		mcpgo.NewTool("echo",
+			mcpgo.WithDescription("Echo a message"),
+			mcpgo.WithString("message", mcpgo.Required(), mcpgo.Description("Message to echo")),
+		),
+		func(_ context.Context, request mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
+			upstream.calls.Add(1)
+			return mcpgo.NewToolResultText(request.GetString("message", "")), nil
+		},
+	)
+	mcpServer.AddTool(
+		mcpgo.NewTool("fail", mcpgo.WithTitleAnnotation("Always fails")),
+		func(context.Context, mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
+			return mcpgo.NewToolResultError("something broke"), nil
+		},
+	)
+	handler := server.NewStreamableHTTPServer(mcpServer)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Mcp-Session-Id") != "" && upstream.expire.CompareAndSwap(true, false) {
+			http.Error(w, "Session terminated", http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Mcp-Session-Id") == "" && r.Method == http.MethodPost {
+			upstream.initializes.Add(1)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	upstream.url = ts.URL + "/mcp"
+	return upstream
+}
+
+func newTestPool(t *testing.T, upstream *testUpstream) *Pool {
+	t.Helper()
+
+	pool, err := NewPool([]config.UpstreamConfig{{
+		Name:     "remote",
+		URL:      upstream.url,
+		Tags:     []string{"remote"},
+		Category: "external",
+	}})
+	require.NoError(t, err)
+	t.Cleanup(func() { pool.Close() })
+	return pool
+}
+
+func TestNewPool(t *testing.T) {
+	tests := map[string]struct {
+		cfgs    []config.UpstreamConfig
+		wantErr string
+	}{
+		"valid": {
+			cfgs: []config.UpstreamConfig{
+				{Name: "github", Command: "github-mcp"},
+				{Name: "docs_2", URL: "http://localhost:9000/mcp"},
+			},
+		},
+		"invalid name": {
+			cfgs:    []config.UpstreamConfig{{Name: "git.hub", Command: "github-mcp"}},
+			wantErr: "must be letters, digits",
+		},
+		"missing name": {
+			cfgs:    []config.UpstreamConfig{{Command: "github-mcp"}},
+			wantErr: "must be letters, digits",
+		},
+		"duplicate name": {
+			cfgs: []config.UpstreamConfig{
+				{Name: "github", Command: "github-mcp"},
+				{Name: "github", URL: "http://localhost:9000/mcp"},
+			},
+			wantErr: `duplicate name "github"`,
+		},
+		"command and url": {
+			cfgs:    []config.UpstreamConfig{{Name: "github", Command: "github-mcp", URL: "http://localhost:9000/mcp"}},
+			wantErr: "exactly one of command and url",
+		},
+		"neither command nor url": {
+			cfgs:    []config.UpstreamConfig{{Name: "github"}},
+			wantErr: "exactly one of command and url",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			pool, err := NewPool(tt.cfgs)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, pool.Clients(), len(tt.cfgs))
+		})
+	}
+}
+
+func TestSplitToolName(t *testing.T) {
+	upstreamName, tool, ok := SplitToolName(ToolName("github", "create.issue"))
+	assert.True(t, ok)
+	assert.Equal(t, "github", upstreamName)
+	assert.Equal(t, "create.issue", tool)
+
+	_, _, ok = SplitToolName("local_tool")
+	assert.False(t, ok)
+}
+
+func TestToolDefinitions(t *testing.T) {
+	pool := newTestPool(t, newTestUpstream(t))
+
+	defs, err := pool.Clients()[0].ToolDefinitions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, defs, 2)
+
+	byName := map[string]int{}
+	for i, def := range defs {
+		byName[def.Name] = i
+	}
+
+	echo := defs[byName["remote.echo"]]
+	assert.Equal(t, "Echo a message", echo.Description)
+	assert.Equal(t, "remote", echo.Source)
+	assert.Equal(t, []string{"remote"}, echo.Tags)
+	assert.Equal(t, "external", echo.Category)
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(echo.InputSchema, &schema))
+	assert.Equal(t, "object", schema["type"])
+	assert.Equal(t, []any{"message"}, schema["required"])
+
+	fail := defs[byName["remote.fail"]]
+	assert.Equal(t, "Always fails", fail.Description)
+}
+
+func TestPoolCallTool(t *testing.T) {
+	pool := newTestPool(t, newTestUpstream(t))
+	ctx := context.Background()
+
+	result, handled, err := pool.CallTool(ctx, "remote.echo", json.RawMessage(`{"message":"hello"}`))
+	require.NoError(t, err)
+	assert.True(t, handled)
+	assert.Equal(t, "hello", result)
+
+	_, handled, err = pool.CallTool(ctx, "remote.fail", nil)
+	assert.True(t, handled)
+	require.Error(t, err)
+	assert.Equal(t, "something broke", err.Error())
+
+	_, handled, err = pool.CallTool(ctx, "other.echo", nil)
+	assert.False(t, handled)
+	assert.NoError(t, err)
+
+	_, handled, err = pool.CallTool(ctx, "echo", nil)
+	assert.False(t, handled)
+	assert.NoError(t, err)
+}
+
+func TestPoolCallToolReconnects(t *testing.T) {
+	upstream := newTestUpstream(t)
+	pool := newTestPool(t, upstream)
+	ctx := context.Background()
+
+	_, _, err := pool.CallTool(ctx, "remote.echo", json.RawMessage(`{"message":"one"}`))
+	require.NoError(t, err)
+
+	upstream.expire.Store(true)
+
+	result, _, err := pool.CallTool(ctx, "remote.echo", json.RawMessage(`{"message":"two"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "two", result)
+	assert.Equal(t, int32(2), upstream.initializes.Load())
+	assert.Equal(t, int32(2), upstream.calls.Load())
+}
+
+func TestPoolCallToolUnreachable(t *testing.T) {
+	pool, err := NewPool([]config.UpstreamConfig{{Name: "down", URL: "http://127.0.0.1:1/mcp"}})
+	require.NoError(t, err)
+
+	_, handled, err := pool.CallTool(context.Background(), "down.echo", nil)
+	assert.True(t, handled)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `failed to connect to upstream "down"`)
+}
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS embedding_cache (
+    provider text not null,
+    model text not null,
+    text_hash text not null,
+    embedding vector not null,
+    created_at timestamptz not null default now(),
+    primary key (provider, model, text_hash)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS embedding_cache;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE tools
+    ADD COLUMN IF NOT EXISTS content_hash text not null default '',
+    ADD COLUMN IF NOT EXISTS embedding_provider text not null default '',
+    ADD COLUMN IF NOT EXISTS embedding_model text not null default '';
+
+-- +goose Down
+ALTER TABLE tools
+    DROP COLUMN IF EXISTS content_hash,
+    DROP COLUMN IF EXISTS embedding_provider,
+    DROP COLUMN IF EXISTS embedding_model;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE tools
+    ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', name), 'A') ||
+        setweight(to_tsvector('english', description), 'B') ||
+        -- Only parameter descriptions; type keywords such as "string" would match every tool
+        setweight(jsonb_to_tsvector('english',
+            jsonb_path_query_array(coalesce(input_schema, '{}'::jsonb), '$.properties.*.description'),
+            '["string"]'), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS tools_search_vector_idx ON tools USING gin (search_vector);
+
+-- +goose Down
+DROP INDEX IF EXISTS tools_search_vector_idx;
+
+ALTER TABLE tools
+    DROP COLUMN IF EXISTS search_vector;
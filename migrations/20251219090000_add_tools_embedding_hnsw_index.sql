@@ -0,0 +1,8 @@
+-- +goose Up
+-- Built with pgvector's defaults; run `marcopolo-go reindex-vectors` to apply the configured search.hnsw parameters.
+CREATE INDEX IF NOT EXISTS tools_embedding_hnsw_idx ON tools
+    USING hnsw (embedding vector_cosine_ops) WITH (m = 16, ef_construction = 64)
+    WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS tools_embedding_hnsw_idx;
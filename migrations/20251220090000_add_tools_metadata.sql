@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE tools
+    ADD COLUMN IF NOT EXISTS tags jsonb not null default '[]',
+    ADD COLUMN IF NOT EXISTS category text not null default '',
+    ADD COLUMN IF NOT EXISTS owner text not null default '';
+
+CREATE INDEX IF NOT EXISTS tools_tags_idx ON tools USING gin (tags);
+CREATE INDEX IF NOT EXISTS tools_category_idx ON tools (category) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS tools_category_idx;
+DROP INDEX IF EXISTS tools_tags_idx;
+
+ALTER TABLE tools
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS category,
+    DROP COLUMN IF EXISTS owner;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Additional embeddings per tool, such as example user queries.
+-- The embedding width must match tools.embedding.
+CREATE TABLE IF NOT EXISTS tool_embeddings (
+    id bigserial primary key,
+    created_at timestamptz not null default now(),
+    tool_id bigint not null references tools(id) on delete cascade,
+    kind text not null,
+    text text not null,
+    embedding vector(1536) not null
+);
+
+CREATE INDEX IF NOT EXISTS tool_embeddings_tool_id_idx ON tool_embeddings (tool_id, kind);
+CREATE INDEX IF NOT EXISTS tool_embeddings_embedding_hnsw_idx ON tool_embeddings
+    USING hnsw (embedding vector_cosine_ops) WITH (m = 16, ef_construction = 64);
+
+-- +goose Down
+DROP TABLE IF EXISTS tool_embeddings;
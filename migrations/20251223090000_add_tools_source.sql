@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE tools
+    ADD COLUMN IF NOT EXISTS source text not null default '';
+
+-- +goose Down
+ALTER TABLE tools
+    DROP COLUMN IF EXISTS source;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- API keys for the HTTP transports. Only a SHA-256 hash of each key is stored;
+-- prefix keeps its first characters so keys can be told apart in listings.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id bigserial primary key,
+    created_at timestamptz not null default now(),
+    name text not null,
+    prefix text not null,
+    key_hash text not null unique,
+    allowed_tools jsonb not null default '[]',
+    allowed_tags jsonb not null default '[]',
+    last_used_at timestamptz,
+    revoked_at timestamptz
+);
+
+-- Names identify active keys; a revoked key's name can be reused
+CREATE UNIQUE INDEX IF NOT EXISTS api_keys_name_active_idx ON api_keys (name) WHERE revoked_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS api_keys;
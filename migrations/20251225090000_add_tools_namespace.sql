@@ -0,0 +1,30 @@
+-- +goose Up
+-- Namespaces partition the catalog; '' is the default namespace.
+-- Tool names are unique among the active tools of a namespace.
+ALTER TABLE tools ADD COLUMN IF NOT EXISTS namespace text not null default '';
+
+DROP INDEX IF EXISTS tools_name_unique_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS tools_namespace_name_unique_idx ON tools (namespace, name) WHERE deleted_at IS NULL;
+
+-- API keys are bound to the namespace their clients may see
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS namespace text not null default '';
+
+-- +goose Down
+-- Dropping the columns would move every tool and key into the default namespace.
+-- Refuse while other namespaces hold data, rather than delete it.
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM tools WHERE namespace <> '') OR EXISTS (SELECT 1 FROM api_keys WHERE namespace <> '') THEN
+        RAISE EXCEPTION 'tools or api_keys exist outside the default namespace; delete them before migrating down';
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS namespace;
+
+DROP INDEX IF EXISTS tools_namespace_name_unique_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS tools_name_unique_idx ON tools (name) WHERE deleted_at IS NULL;
+
+ALTER TABLE tools DROP COLUMN IF EXISTS namespace;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Persistent query cache entries are pruned by last use
+ALTER TABLE embedding_cache ADD COLUMN IF NOT EXISTS last_used_at timestamptz not null default now();
+CREATE INDEX IF NOT EXISTS embedding_cache_last_used_at_idx ON embedding_cache (last_used_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS embedding_cache_last_used_at_idx;
+ALTER TABLE embedding_cache DROP COLUMN IF EXISTS last_used_at;